@@ -0,0 +1,175 @@
+package hasaki
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultBatchConcurrency = 8
+
+// BatchMode 批量请求的错误处理模式
+// Error handling mode of a batch
+type BatchMode uint8
+
+const (
+	BatchCollectAll BatchMode = iota // 执行所有请求, 收集全部结果
+	BatchFailFast                    // 第一个请求失败后取消其余请求
+)
+
+type batchItem struct {
+	request *Request
+	body    any
+}
+
+// Batch 以有限的并发执行一组请求, 按添加顺序返回响应.
+// 每个请求仍然通过Request.Send发送, 中间件和编解码器照常生效; 响应体总是预先读取, 可以在Run返回后读取.
+// Executes a group of requests with bounded concurrency, returning responses in the order they were added.
+// Every request is still sent by Request.Send so middlewares and codecs apply; bodies are always buffered and can be read after Run returns.
+type Batch struct {
+	items       []batchItem
+	concurrency int
+	mode        BatchMode
+	timeout     time.Duration
+	onResult    func(index int, resp *Response)
+}
+
+// NewBatch 创建批量请求, 默认并发数为8, 模式为BatchCollectAll
+// Creating a batch, the default concurrency is 8 and the default mode is BatchCollectAll
+func NewBatch() *Batch {
+	return &Batch{concurrency: defaultBatchConcurrency, mode: BatchCollectAll}
+}
+
+// Add 添加一个请求, body与Request.Send的参数相同.
+// Run发送的是请求的副本, 原请求不会被修改, 同一个请求可以添加多次; 请求上下文中的值和截止时间仍然有效, Run的上下文被取消时请求也会被取消.
+// Adding a request, body is the same as the argument of Request.Send.
+// Run sends a copy of the request, so the original is never modified and may be added more than once;
+// the values and deadline of the request context still apply, and the request is also canceled when the context of Run is canceled.
+func (c *Batch) Add(request *Request, body any) *Batch {
+	c.items = append(c.items, batchItem{request: request, body: body})
+	return c
+}
+
+// Len 返回请求数量
+// Returns the number of requests
+func (c *Batch) Len() int {
+	return len(c.items)
+}
+
+// SetConcurrency 设置最大并发数
+// Setting the maximum concurrency
+func (c *Batch) SetConcurrency(n int) *Batch {
+	if n > 0 {
+		c.concurrency = n
+	}
+	return c
+}
+
+// SetMode 设置错误处理模式
+// Setting the error handling mode
+func (c *Batch) SetMode(mode BatchMode) *Batch {
+	c.mode = mode
+	return c
+}
+
+// SetTimeout 设置单个请求的超时时间, 包含读取响应体的时间
+// Setting the timeout of each request, including reading the response body
+func (c *Batch) SetTimeout(d time.Duration) *Batch {
+	c.timeout = d
+	return c
+}
+
+// OnResult 设置结果回调, 每个请求完成后按完成顺序调用; 回调不会被并发调用
+// Setting the result callback, which is called in completion order as each request finishes; it is never called concurrently
+func (c *Batch) OnResult(fn func(index int, resp *Response)) *Batch {
+	c.onResult = fn
+	return c
+}
+
+// Run 使用共享的上下文执行所有请求, 返回按添加顺序排列的响应和第一个错误.
+// BatchFailFast模式下, 未开始的请求的错误为context.Canceled.
+// Runs all requests with the shared context, returning responses in the order they were added and the first error.
+// In BatchFailFast mode, requests that were not started fail with context.Canceled.
+func (c *Batch) Run(ctx context.Context) ([]*Response, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var results = make([]*Response, len(c.items))
+	var jobs = make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	var firstIndex = -1
+
+	var concurrency = c.concurrency
+	if concurrency > len(c.items) {
+		concurrency = len(c.items)
+	}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				var resp = c.do(ctx, c.items[index])
+				mu.Lock()
+				results[index] = resp
+				if resp.err != nil && c.mode == BatchFailFast && firstIndex < 0 {
+					firstErr, firstIndex = resp.err, index
+					cancel()
+				}
+				if c.onResult != nil {
+					c.onResult(index, resp)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range c.items {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, resp := range results {
+		if resp == nil {
+			results[i] = &Response{ctx: ctx, err: errors.WithStack(ctx.Err())}
+		}
+		if firstIndex < 0 && results[i].err != nil {
+			firstErr, firstIndex = results[i].err, i
+		}
+	}
+	return results, firstErr
+}
+
+func (c *Batch) do(ctx context.Context, item batchItem) *Response {
+	// 基于请求自身的上下文, 并在批量上下文取消时取消
+	var request = item.request.clone()
+	reqCtx, cancel := context.WithCancel(request.ctx)
+	defer cancel()
+	var stop = context.AfterFunc(ctx, cancel)
+	defer stop()
+	if c.timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		reqCtx, cancelTimeout = context.WithTimeout(reqCtx, c.timeout)
+		defer cancelTimeout()
+	}
+
+	var resp = request.SetContext(reqCtx).Send(item.body)
+	if resp.err != nil || resp.Response == nil || resp.Body == nil {
+		return resp
+	}
+	// 在上下文取消前读取body
+	if _, ok := resp.Body.(BytesReadCloser); !ok {
+		resp.err = request.readBody(resp)
+	}
+	return resp
+}
@@ -0,0 +1,145 @@
+package hasaki
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch(t *testing.T) {
+	var running, maxRunning int64
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var n = atomic.AddInt64(&running, 1)
+		defer atomic.AddInt64(&running, -1)
+		for {
+			var m = atomic.LoadInt64(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt64(&maxRunning, m, n) {
+				break
+			}
+		}
+
+		id, _ := strconv.Atoi(request.URL.Query().Get("id"))
+		time.Sleep(time.Duration(10-id%10) * time.Millisecond)
+		switch request.URL.Path {
+		case "/error":
+			writer.WriteHeader(http.StatusInternalServerError)
+		case "/slow":
+			time.Sleep(time.Second)
+		default:
+			writer.Write([]byte(strconv.Itoa(id)))
+		}
+	}))
+	go srv.ListenAndServe()
+	time.Sleep(100 * time.Millisecond)
+
+	var cli, _ = NewClient()
+
+	t.Run("collect all", func(t *testing.T) {
+		atomic.StoreInt64(&maxRunning, 0)
+		var batch = NewBatch().SetConcurrency(4)
+		for i := 0; i < 20; i++ {
+			batch.Add(cli.Get("http://%s?id=%d", addr, i), nil)
+		}
+		var calls int64
+		batch.OnResult(func(index int, resp *Response) { calls++ })
+
+		results, err := batch.Run(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, len(results), 20)
+		assert.Equal(t, calls, int64(20))
+		assert.LessOrEqual(t, atomic.LoadInt64(&maxRunning), int64(4))
+		for i, resp := range results {
+			p, err := resp.ReadBody()
+			assert.NoError(t, err)
+			assert.Equal(t, string(p), strconv.Itoa(i))
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		var batch = NewBatch().SetConcurrency(1).SetMode(BatchFailFast)
+		batch.Add(cli.Get("http://%s?id=1", addr), nil)
+		batch.Add(cli.Get("http://%s", nextAddr()), nil)
+		batch.Add(cli.Get("http://%s?id=3", addr), nil)
+		results, err := batch.Run(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, batch.Len(), 3)
+		assert.NoError(t, results[0].Err())
+		assert.Equal(t, results[1].Err(), err)
+		assert.True(t, errors.Is(results[2].Err(), context.Canceled))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		var batch = NewBatch().SetTimeout(50 * time.Millisecond)
+		batch.Add(cli.Get("http://%s/slow", addr), nil)
+		batch.Add(cli.Post("http://%s?id=2", addr), Any{"name": "caster"})
+		results, err := batch.Run(context.Background())
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		p, err := results[1].ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), "2")
+	})
+
+	t.Run("status code is not an error", func(t *testing.T) {
+		results, err := NewBatch().SetMode(BatchFailFast).Add(cli.Get("http://%s/error", addr), nil).Run(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, results[0].StatusCode, http.StatusInternalServerError)
+	})
+
+	t.Run("request context", func(t *testing.T) {
+		type ctxKey struct{}
+		var reqCtx, cancel = context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "hasaki"), 50*time.Millisecond)
+		defer cancel()
+
+		var values []any
+		var request = cli.Get("http://%s?id=1", addr).SetContext(reqCtx).SetBefore(func(ctx context.Context, request *http.Request) (context.Context, error) {
+			values = append(values, ctx.Value(ctxKey{}))
+			return ctx, nil
+		})
+		var batch = NewBatch().SetConcurrency(1).Add(request, nil).Add(request, nil)
+		batch.Add(cli.Get("http://%s/slow", addr).SetContext(reqCtx), nil)
+		results, err := batch.Run(context.Background())
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Equal(t, values, []any{"hasaki", "hasaki"})
+		assert.NoError(t, results[0].Err())
+		assert.NoError(t, results[1].Err())
+		assert.Equal(t, request.ctx, reqCtx)
+	})
+
+	t.Run("same request", func(t *testing.T) {
+		var request = cli.Get("http://%s?id=7", addr)
+		var batch = NewBatch().SetConcurrency(4)
+		for i := 0; i < 8; i++ {
+			batch.Add(request, nil)
+		}
+		results, err := batch.Run(context.Background())
+		assert.NoError(t, err)
+		for _, resp := range results {
+			p, _ := resp.ReadBody()
+			assert.Equal(t, string(p), "7")
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		var ctx, cancel = context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		var request = cli.Get("http://%s/slow", addr)
+		results, err := NewBatch().Add(request, nil).Run(ctx)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.True(t, errors.Is(results[0].Err(), context.Canceled))
+		assert.NoError(t, request.ctx.Err())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		results, err := NewBatch().Run(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, len(results), 0)
+	})
+}
@@ -0,0 +1,111 @@
+package hasaki
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// 嗅探XML声明和HTML meta标签时读取的最大字节数
+const charsetSniffLen = 1024
+
+var (
+	errUnsupportedCharset = errors.New("unsupported charset")
+
+	xmlEncodingRegexp  = regexp.MustCompile(`^\s*<\?xml[^>]*?\sencoding\s*=\s*["']([\w.:-]+)["']`)
+	metaCharsetRegexp  = regexp.MustCompile(`(?i)<meta[^>]*?charset\s*=\s*["']?\s*([\w.:-]+)`)
+	utf8BOM            = []byte{0xEF, 0xBB, 0xBF}
+	charsetMarkupTypes = []string{"xml", "html"}
+)
+
+// charsetReader 已经转换为UTF-8的响应体; 保留原始数据, formCodec需要先解析再按字段转换
+type charsetReader struct {
+	io.Reader
+	raw      io.Reader
+	encoding encoding.Encoding
+}
+
+// lookupCharset 按WHATWG标签查找编码, 例如gbk, gb18030, big5; UTF-8和未知标签返回nil
+func lookupCharset(label string) encoding.Encoding {
+	if label == "" {
+		return nil
+	}
+	enc, err := htmlindex.Get(strings.TrimSpace(label))
+	if err != nil || enc == unicode.UTF8 {
+		return nil
+	}
+	return enc
+}
+
+// detectCharset 依次按Content-Type的charset参数, XML声明和HTML meta标签确定编码; 只有XML/HTML或者未知类型才嗅探内容
+func detectCharset(contentType string, head func() []byte) encoding.Encoding {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if label := params["charset"]; label != "" {
+		return lookupCharset(label)
+	}
+	if contentType != "" && !containsAny(mediaType, charsetMarkupTypes) {
+		return nil
+	}
+	return lookupCharset(sniffCharset(head()))
+}
+
+// sniffCharset 从XML声明或HTML meta标签中提取编码名称
+func sniffCharset(head []byte) string {
+	if bytes.HasPrefix(head, utf8BOM) {
+		return ""
+	}
+	if m := xmlEncodingRegexp.FindSubmatch(head); m != nil {
+		return string(m[1])
+	}
+	if m := metaCharsetRegexp.FindSubmatch(head); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// newXmlCharsetReader 用作xml.Decoder.CharsetReader, 支持XML声明中的GBK, GB18030, Big5等编码
+func newXmlCharsetReader(label string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, errors.Wrapf(errUnsupportedCharset, "charset=%s", label)
+	}
+	return transform.NewReader(input, enc.NewDecoder()), nil
+}
+
+// decodeValues 将按enc编码的表单键值转换为UTF-8
+func decodeValues(values url.Values, enc encoding.Encoding) (url.Values, error) {
+	var decoder = enc.NewDecoder()
+	var result = make(url.Values, len(values))
+	for k, items := range values {
+		key, err := decoder.String(k)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, item := range items {
+			value, err := decoder.String(item)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			result[key] = append(result[key], value)
+		}
+	}
+	return result, nil
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, item := range substrings {
+		if strings.Contains(s, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package hasaki
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+func encodeString(enc encoding.Encoding, s string) string {
+	result, _ := enc.NewEncoder().String(s)
+	return result
+}
+
+func newCharsetResponse(contentType string, body string) *Response {
+	var header = http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &Response{Response: &http.Response{Header: header, Body: io.NopCloser(strings.NewReader(body))}}
+}
+
+func TestDetectCharset(t *testing.T) {
+	var head = func(s string) func() []byte { return func() []byte { return []byte(s) } }
+
+	assert.Equal(t, detectCharset("text/plain; charset=GBK", head("")), encoding.Encoding(simplifiedchinese.GBK))
+	assert.Equal(t, detectCharset("text/plain; charset=gb2312", head("")), encoding.Encoding(simplifiedchinese.GBK))
+	assert.Equal(t, detectCharset(`application/json;charset="big5"`, head("")), encoding.Encoding(traditionalchinese.Big5))
+	assert.Nil(t, detectCharset(MimeJson, head("")))
+	assert.Nil(t, detectCharset("text/plain; charset=unknown", head("")))
+	assert.Nil(t, detectCharset("application/json", head(`<meta charset="gbk">`)))
+
+	assert.Equal(t, detectCharset("application/xml", head(`<?xml version="1.0" encoding="GB18030"?><a/>`)), encoding.Encoding(simplifiedchinese.GB18030))
+	assert.Equal(t, detectCharset("", head(`<?xml version='1.0' encoding='gbk' ?><a/>`)), encoding.Encoding(simplifiedchinese.GBK))
+	assert.Equal(t, detectCharset("text/html", head(`<html><head><meta charset="big5"></head>`)), encoding.Encoding(traditionalchinese.Big5))
+	assert.Equal(t, detectCharset("text/html", head(`<META http-equiv="Content-Type" content="text/html; charset=GB2312">`)), encoding.Encoding(simplifiedchinese.GBK))
+	assert.Nil(t, detectCharset("text/html", head("\xEF\xBB\xBF<meta charset=\"gbk\">")))
+	assert.Nil(t, detectCharset("text/html", head(`<html></html>`)))
+}
+
+func TestResponse_ReadBody_Charset(t *testing.T) {
+	var text = "中国上海"
+
+	t.Run("content type", func(t *testing.T) {
+		p, err := newCharsetResponse("text/plain; charset=gbk", encodeString(simplifiedchinese.GBK, text)).ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), text)
+	})
+
+	t.Run("meta", func(t *testing.T) {
+		var html = `<html><head><meta charset="gb18030"></head><body>` + text + `</body></html>`
+		p, err := newCharsetResponse("text/html", encodeString(simplifiedchinese.GB18030, html)).ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), html)
+	})
+
+	t.Run("utf8", func(t *testing.T) {
+		p, err := newCharsetResponse(MimeJson, `"`+text+`"`).ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), `"`+text+`"`)
+	})
+}
+
+func TestResponse_Bind_Charset(t *testing.T) {
+	type People struct {
+		Name    string `json:"name" xml:"name"`
+		Address string `json:"address" xml:"address"`
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var body = encodeString(traditionalchinese.Big5, `{"name":"王小明","address":"台北"}`)
+		var v People
+		assert.NoError(t, newCharsetResponse("application/json; charset=big5", body).BindJSON(&v))
+		assert.Equal(t, v, People{Name: "王小明", Address: "台北"})
+	})
+
+	t.Run("xml declaration", func(t *testing.T) {
+		var body = encodeString(simplifiedchinese.GBK, `<?xml version="1.0" encoding="GBK"?><people><name>张三</name><address>中国上海</address></people>`)
+		var v People
+		assert.NoError(t, newCharsetResponse("application/xml", body).BindXML(&v))
+		assert.Equal(t, v, People{Name: "张三", Address: "中国上海"})
+
+		// Content-Type优先于XML声明
+		v = People{}
+		assert.NoError(t, newCharsetResponse("text/xml; charset=gb18030", body).BindXML(&v))
+		assert.Equal(t, v, People{Name: "张三", Address: "中国上海"})
+
+		v = People{}
+		assert.NoError(t, XmlCodec.Decode(strings.NewReader(body), &v))
+		assert.Equal(t, v, People{Name: "张三", Address: "中国上海"})
+	})
+
+	t.Run("xml unsupported", func(t *testing.T) {
+		var v People
+		var err = XmlCodec.Decode(strings.NewReader(`<?xml version="1.0" encoding="x-unknown"?><people/>`), &v)
+		assert.True(t, errors.Is(err, errUnsupportedCharset))
+	})
+
+	t.Run("form", func(t *testing.T) {
+		var body = url.Values{
+			encodeString(simplifiedchinese.GBK, "城市"): {encodeString(simplifiedchinese.GBK, "上海"), encodeString(simplifiedchinese.GBK, "北京")},
+		}.Encode()
+		var v url.Values
+		assert.NoError(t, newCharsetResponse(MimeForm+"; charset=gbk", body).BindForm(&v))
+		assert.Equal(t, v, url.Values{"城市": {"上海", "北京"}})
+
+		assert.Error(t, newCharsetResponse(MimeForm+"; charset=gbk", "a=%zz").BindForm(&v))
+	})
+
+	t.Run("reuse body", func(t *testing.T) {
+		addr := nextAddr()
+		srv := &http.Server{Addr: addr}
+		srv.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Content-Type", "application/json; charset=gbk")
+			writer.Write([]byte(encodeString(simplifiedchinese.GBK, `{"name":"张三"}`)))
+		})
+		go srv.ListenAndServe()
+		defer srv.Close()
+		time.Sleep(100 * time.Millisecond)
+
+		for _, reuse := range []bool{false, true} {
+			var options []Option
+			if reuse {
+				options = append(options, WithReuseBody())
+			}
+			cli, _ := NewClient(options...)
+			var v People
+			assert.NoError(t, cli.Get("http://"+addr).Send(nil).BindJSON(&v))
+			assert.Equal(t, v.Name, "张三")
+
+			p, err := cli.Get("http://" + addr).Send(nil).ReadBody()
+			assert.NoError(t, err)
+			assert.Equal(t, string(p), `{"name":"张三"}`)
+		}
+	})
+}
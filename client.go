@@ -65,6 +65,13 @@ func (c *Client) Request(method string, url string, args ...any) *Request {
 		after:            c.config.AfterFunc,
 		headers:          http.Header{},
 		reuseBodyEnabled: c.config.ReuseBodyEnabled,
+		redactor:         c.config.Redactor,
+		har:              c.config.HARRecorder,
+		maxResponseSize:  c.config.MaxResponseSize,
+	}
+
+	if c.config.Logger != nil {
+		r.logger = &logger{Logger: c.config.Logger, conf: c.config.LogConfig, redactor: c.config.Redactor}
 	}
 
 	r.SetEncoder(JsonCodec)
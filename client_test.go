@@ -410,3 +410,36 @@ func TestRequest_ReadBody(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestWithTransportMiddleware(t *testing.T) {
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("x-trace", request.Header.Get("x-trace"))
+		writer.WriteHeader(http.StatusOK)
+	}))
+	go srv.ListenAndServe()
+	time.Sleep(100 * time.Millisecond)
+
+	var middleware = func(name string) TransportMiddleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				r = r.Clone(r.Context())
+				r.Header.Add("x-trace", name)
+				return next.RoundTrip(r)
+			})
+		}
+	}
+
+	var httpClient = &http.Client{}
+	var cli, _ = NewClient(
+		WithHTTPClient(httpClient),
+		WithTransportMiddleware(middleware("a")),
+		WithTransportMiddleware(middleware("b")),
+	)
+	var resp = cli.Get("http://%s", addr).Send(nil)
+	assert.NoError(t, resp.Err())
+	assert.Equal(t, resp.Header.Get("x-trace"), "a")
+	assert.Equal(t, resp.Request.Header.Values("x-trace"), []string{"a", "b"})
+	assert.Nil(t, httpClient.Transport)
+}
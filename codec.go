@@ -3,13 +3,14 @@ package hasaki
 import (
 	"bytes"
 	"encoding/xml"
-	jsoniter "github.com/json-iterator/go"
 	"github.com/lxzan/hasaki/internal"
 	"github.com/pkg/errors"
 	"github.com/valyala/bytebufferpool"
+	"golang.org/x/text/encoding"
 	"io"
 	"net/url"
 	"strings"
+	"sync"
 )
 
 const (
@@ -17,6 +18,12 @@ const (
 	MimeYaml     = "application/x-yaml;charset=utf-8"
 	MimeXml      = "application/xml;charset=utf-8"
 	MimeProtoBuf = "application/x-protobuf"
+	MimeMsgpack  = "application/msgpack"
+	MimeCbor     = "application/cbor"
+	MimeToml     = "application/toml"
+	MimeJson5    = "application/json5"
+	MimeCsv      = "text/csv;charset=utf-8"
+	MimeTsv      = "text/tab-separated-values;charset=utf-8"
 	MimeForm     = "application/x-www-form-urlencoded"
 	MimeStream   = "application/octet-stream"
 	MimeJpeg     = "image/jpeg"
@@ -44,35 +51,58 @@ type (
 )
 
 var (
-	JsonCodec = new(jsonCodec)
+	JsonCodec = NewJsonCodec()
 	FormCodec = new(formCodec)
 	XmlCodec  = new(xmlCodec)
 )
 
-type (
-	jsonCodec struct{}
-	formCodec struct{}
-	xmlCodec  struct{}
-)
+var codecs = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{
+	m: map[string]Codec{
+		"application/json":                  JsonCodec,
+		"application/xml":                   XmlCodec,
+		"application/x-www-form-urlencoded": FormCodec,
+		"text/csv":                          CsvCodec,
+		"text/tab-separated-values":         TsvCodec,
+	},
+}
 
-func (c jsonCodec) Encode(v any) (io.Reader, error) {
-	if v == nil {
-		return nil, nil
+// RegisterCodec 按媒体类型注册编解码器, 媒体类型为空时使用codec.ContentType(); 参数(如charset)会被忽略.
+// contrib中的msgpack, cbor, yaml, toml, json5和pb(仅二进制格式)在导入时自动注册.
+// Registering a codec by media types, codec.ContentType() is used when none is given; parameters such as charset are ignored.
+// The msgpack, cbor, yaml, toml, json5 and pb (binary only) codecs in contrib register themselves when imported.
+func RegisterCodec(codec Codec, mediaTypes ...string) {
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{codec.ContentType()}
+	}
+	codecs.Lock()
+	defer codecs.Unlock()
+	for _, item := range mediaTypes {
+		codecs.m[mediaType(item)] = codec
 	}
-	w := bytebufferpool.Get()
-	err := jsoniter.ConfigFastest.NewEncoder(w).Encode(v)
-	r := &internal.CloserWrapper{B: w, R: bytes.NewReader(w.B)}
-	return r, errors.WithStack(err)
 }
 
-func (c jsonCodec) ContentType() string {
-	return MimeJson
+// GetCodec 按Content-Type查找已注册的编解码器
+// Looking up a registered codec by Content-Type
+func GetCodec(contentType string) (Codec, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	codec, ok := codecs.m[mediaType(contentType)]
+	return codec, ok
 }
 
-func (c jsonCodec) Decode(r io.Reader, v any) error {
-	return jsoniter.ConfigFastest.NewDecoder(r).Decode(v)
+func mediaType(contentType string) string {
+	s, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(s))
 }
 
+type (
+	formCodec struct{}
+	xmlCodec  struct{}
+)
+
 func (f formCodec) Encode(v any) (io.Reader, error) {
 	if v == nil {
 		return nil, nil
@@ -96,11 +126,22 @@ func (f formCodec) Decode(r io.Reader, v any) error {
 	if !ok {
 		return errors.Wrap(errUnsupportedData, "v must be *url.Values type")
 	}
+	// 百分号编码的是原始字节, 需要先解析再按字段转换为UTF-8
+	var enc encoding.Encoding
+	if cr, ok := r.(*charsetReader); ok {
+		r, enc = cr.raw, cr.encoding
+	}
 	var builder = &strings.Builder{}
 	var temp = internal.GetBuffer()
-	_, _ = io.CopyBuffer(builder, r, temp.Bytes()[:internal.BufferSize])
+	_, err := io.CopyBuffer(builder, r, temp.Bytes()[:internal.BufferSize])
 	internal.PutBuffer(temp)
+	if err != nil {
+		return errors.WithStack(err)
+	}
 	result, err := url.ParseQuery(builder.String())
+	if err == nil && enc != nil {
+		result, err = decodeValues(result, enc)
+	}
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -122,8 +163,15 @@ func (c xmlCodec) ContentType() string {
 	return MimeXml
 }
 
+// Decode 解码; 支持XML声明中的非UTF-8编码. 响应体已按Content-Type转换为UTF-8时忽略声明中的编码
+// Decoding; non UTF-8 encodings in the XML declaration are supported. The declared encoding is ignored when the body was already converted to UTF-8 by Content-Type
 func (c xmlCodec) Decode(r io.Reader, v any) error {
-	return errors.WithStack(xml.NewDecoder(r).Decode(v))
+	var decoder = xml.NewDecoder(r)
+	decoder.CharsetReader = newXmlCharsetReader
+	if _, ok := r.(*charsetReader); ok {
+		decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	}
+	return errors.WithStack(decoder.Decode(v))
 }
 
 type streamEncoder struct {
@@ -168,3 +168,27 @@ func TestXmlDecode(t *testing.T) {
 		assert.Equal(t, params.Peos[0].Id, 888)
 	})
 }
+
+func TestGetCodec(t *testing.T) {
+	codec, ok := GetCodec(MimeJson)
+	assert.True(t, ok)
+	assert.Equal(t, codec, Codec(JsonCodec))
+
+	codec, ok = GetCodec(" Text/CSV; header=present")
+	assert.True(t, ok)
+	assert.Equal(t, codec, CsvCodec)
+
+	_, ok = GetCodec("application/unknown")
+	assert.False(t, ok)
+
+	RegisterCodec(JsonCodec, "application/problem+json", "application/vnd.api+json")
+	codec, ok = GetCodec("application/problem+json;charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, codec, Codec(JsonCodec))
+
+	var encoder = NewCsvCodec(WithCsvComma(';'))
+	RegisterCodec(encoder)
+	codec, _ = GetCodec(MimeCsv)
+	assert.Equal(t, codec, encoder)
+	RegisterCodec(CsvCodec)
+}
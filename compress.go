@@ -0,0 +1,242 @@
+package hasaki
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/lxzan/hasaki/internal"
+	"github.com/pkg/errors"
+	"github.com/valyala/bytebufferpool"
+)
+
+const defaultCompressMinSize = 1024
+
+var errUnsupportedCompression = errors.New("unsupported compression")
+
+// Compression 压缩算法, 取值为Content-Encoding
+// Compression algorithm, the value is used as Content-Encoding
+type Compression string
+
+const (
+	CompressionGzip    Compression = "gzip"
+	CompressionDeflate Compression = "deflate" // zlib格式, 见RFC 9110 8.4.1.2
+	CompressionZstd    Compression = "zstd"
+	CompressionBrotli  Compression = "br"
+)
+
+type (
+	compressConfig struct {
+		level   int
+		minSize int
+		stream  bool
+	}
+
+	// CompressOption 压缩编码器配置
+	// Compressed encoder option
+	CompressOption func(c *compressConfig)
+)
+
+// WithCompressLevel 设置压缩级别, 取值范围由算法决定; 默认使用算法的默认级别
+// Setting the compression level, the range depends on the algorithm; the algorithm's default level is used by default
+func WithCompressLevel(level int) CompressOption {
+	return func(c *compressConfig) {
+		c.level = level
+	}
+}
+
+// WithCompressMinSize 设置压缩阈值, 请求体小于该值时不压缩, 默认为1KB
+// Setting the compression threshold, bodies smaller than it are sent uncompressed, default is 1KB
+func WithCompressMinSize(n int) CompressOption {
+	return func(c *compressConfig) {
+		c.minSize = n
+	}
+}
+
+// WithCompressStream 通过管道边编码边压缩, 适用于大请求体; 默认压缩到缓冲池中
+// Compressing through a pipe while encoding, suitable for large bodies; bodies are compressed into a pooled buffer by default
+func WithCompressStream() CompressOption {
+	return func(c *compressConfig) {
+		c.stream = true
+	}
+}
+
+type compressWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+type compressedEncoder struct {
+	inner     Encoder
+	algo      Compression
+	conf      *compressConfig
+	newWriter func(w io.Writer) (compressWriter, error)
+	pool      sync.Pool
+}
+
+// NewCompressedEncoder 包装编码器, 压缩编码结果并设置Content-Encoding, Content-Type保持不变
+// Wrapping an encoder to compress its output and set Content-Encoding, the Content-Type is unchanged
+func NewCompressedEncoder(inner Encoder, algo Compression, options ...CompressOption) Encoder {
+	var conf = &compressConfig{level: -1, minSize: defaultCompressMinSize}
+	for _, f := range options {
+		f(conf)
+	}
+	var c = &compressedEncoder{inner: inner, algo: algo, conf: conf}
+	switch algo {
+	case CompressionGzip:
+		c.newWriter = func(w io.Writer) (compressWriter, error) { return gzip.NewWriterLevel(w, conf.level) }
+	case CompressionDeflate:
+		c.newWriter = func(w io.Writer) (compressWriter, error) { return zlib.NewWriterLevel(w, conf.level) }
+	case CompressionZstd:
+		var level = zstd.SpeedDefault
+		if conf.level > 0 {
+			level = zstd.EncoderLevelFromZstd(conf.level)
+		}
+		c.newWriter = func(w io.Writer) (compressWriter, error) {
+			return zstd.NewWriter(w, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+		}
+	case CompressionBrotli:
+		var level = brotli.DefaultCompression
+		if conf.level >= 0 {
+			level = conf.level
+		}
+		c.newWriter = func(w io.Writer) (compressWriter, error) { return brotli.NewWriterLevel(w, level), nil }
+	}
+	return c
+}
+
+func (c *compressedEncoder) ContentType() string {
+	return c.inner.ContentType()
+}
+
+func (c *compressedEncoder) Encode(v any) (io.Reader, error) {
+	if c.newWriter == nil {
+		return nil, errors.Wrapf(errUnsupportedCompression, "algo=%s", c.algo)
+	}
+	r, err := c.inner.Encode(v)
+	if err != nil || r == nil {
+		return r, err
+	}
+	if c.conf.stream {
+		return c.encodeStream(r)
+	}
+	return c.encodeBuffer(r)
+}
+
+func (c *compressedEncoder) encodeBuffer(r io.Reader) (io.Reader, error) {
+	var src []byte
+	if br, ok := r.(BytesReadCloser); ok {
+		src = br.Bytes()
+	} else {
+		var b = bytebufferpool.Get()
+		_, err := b.ReadFrom(r)
+		if err != nil {
+			bytebufferpool.Put(b)
+			closeReader(r)
+			return nil, errors.WithStack(err)
+		}
+		closeReader(r)
+		r, src = &internal.CloserWrapper{B: b, R: bytes.NewReader(b.B)}, b.B
+	}
+	if len(src) < c.conf.minSize {
+		return r, nil
+	}
+
+	var w = bytebufferpool.Get()
+	cw, err := c.getWriter(w)
+	if err == nil {
+		if _, err = cw.Write(src); err == nil {
+			err = cw.Close()
+		}
+		c.pool.Put(cw)
+	}
+	closeReader(r)
+	if err != nil {
+		bytebufferpool.Put(w)
+		return nil, errors.WithStack(err)
+	}
+	return &compressedReader{ReadCloser: &internal.CloserWrapper{B: w, R: bytes.NewReader(w.B)}, encoding: c.algo}, nil
+}
+
+// encodeStream 先读取minSize字节判断是否需要压缩, 首次读取时才启动压缩协程
+func (c *compressedEncoder) encodeStream(r io.Reader) (io.Reader, error) {
+	var head = make([]byte, c.conf.minSize)
+	n, err := io.ReadFull(r, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		closeReader(r)
+		return bytes.NewReader(head[:n]), nil
+	}
+	if err != nil {
+		closeReader(r)
+		return nil, errors.WithStack(err)
+	}
+
+	var src = io.MultiReader(bytes.NewReader(head), r)
+	var pr, pw = io.Pipe()
+	var reader = &compressedReader{ReadCloser: pr, encoding: c.algo}
+	reader.start = func() {
+		go func() {
+			cw, err := c.getWriter(pw)
+			if err == nil {
+				if _, err = io.Copy(cw, src); err == nil {
+					err = cw.Close()
+				}
+				c.pool.Put(cw)
+			}
+			closeReader(r)
+			_ = pw.CloseWithError(err)
+		}()
+	}
+	reader.stop = func() { closeReader(r) }
+	return reader, nil
+}
+
+func (c *compressedEncoder) getWriter(w io.Writer) (compressWriter, error) {
+	if v := c.pool.Get(); v != nil {
+		var cw = v.(compressWriter)
+		cw.Reset(w)
+		return cw, nil
+	}
+	return c.newWriter(w)
+}
+
+// compressedReader 压缩后的请求体, Send根据它设置Content-Encoding
+type compressedReader struct {
+	io.ReadCloser
+	encoding Compression
+	once     sync.Once
+	start    func()
+	stop     func()
+}
+
+func (c *compressedReader) Read(p []byte) (int, error) {
+	if c.start != nil {
+		c.once.Do(c.start)
+	}
+	return c.ReadCloser.Read(p)
+}
+
+func (c *compressedReader) Close() error {
+	if c.stop != nil {
+		var started = true
+		c.once.Do(func() { started = false })
+		if !started {
+			c.stop()
+		}
+	}
+	return c.ReadCloser.Close()
+}
+
+func (c *compressedReader) ContentEncoding() string {
+	return string(c.encoding)
+}
+
+func closeReader(r io.Reader) {
+	if closer, ok := r.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
@@ -0,0 +1,179 @@
+package hasaki
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func decompress(t *testing.T, encoding string, r io.Reader) []byte {
+	var reader io.Reader
+	var err error
+	switch encoding {
+	case "gzip":
+		reader, err = gzip.NewReader(r)
+	case "deflate":
+		reader, err = zlib.NewReader(r)
+	case "zstd":
+		var dec *zstd.Decoder
+		dec, err = zstd.NewReader(r)
+		if err == nil {
+			defer dec.Close()
+		}
+		reader = dec
+	case "br":
+		reader = brotli.NewReader(r)
+	default:
+		reader = r
+	}
+	assert.NoError(t, err)
+	p, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	return p
+}
+
+func TestNewCompressedEncoder(t *testing.T) {
+	var data = map[string]string{"text": strings.Repeat("hasaki ", 500)}
+	var expected, _ = JsonCodec.Encode(data)
+	var raw, _ = io.ReadAll(expected)
+
+	for _, algo := range []Compression{CompressionGzip, CompressionDeflate, CompressionZstd, CompressionBrotli} {
+		for _, stream := range []bool{false, true} {
+			var options = []CompressOption{WithCompressLevel(3)}
+			if stream {
+				options = append(options, WithCompressStream())
+			}
+			var encoder = NewCompressedEncoder(JsonCodec, algo, options...)
+			assert.Equal(t, encoder.ContentType(), MimeJson)
+
+			for i := 0; i < 2; i++ {
+				r, err := encoder.Encode(data)
+				assert.NoError(t, err)
+				cr, ok := r.(*compressedReader)
+				assert.True(t, ok)
+				assert.Equal(t, cr.ContentEncoding(), string(algo))
+				p, _ := io.ReadAll(cr)
+				assert.NoError(t, cr.Close())
+				assert.Less(t, len(p), len(raw))
+				assert.Equal(t, decompress(t, string(algo), bytes.NewReader(p)), raw)
+			}
+		}
+	}
+}
+
+func TestCompressedEncoder_Threshold(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		var options = []CompressOption{WithCompressMinSize(64)}
+		if stream {
+			options = append(options, WithCompressStream())
+		}
+		var encoder = NewCompressedEncoder(JsonCodec, CompressionGzip, options...)
+
+		r, err := encoder.Encode(map[string]string{"name": "caster"})
+		assert.NoError(t, err)
+		_, ok := r.(*compressedReader)
+		assert.False(t, ok)
+		p, _ := io.ReadAll(r)
+		assert.Equal(t, string(p), "{\"name\":\"caster\"}\n")
+
+		r, err = encoder.Encode(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, r)
+
+		// 读取器没有Bytes方法时复制到缓冲池
+		r, err = NewCompressedEncoder(NewStreamEncoder(MimeStream), CompressionGzip, options...).Encode(strings.Repeat("a", 100))
+		assert.NoError(t, err)
+		_, ok = r.(*compressedReader)
+		assert.True(t, ok)
+		assert.Equal(t, string(decompress(t, "gzip", r)), strings.Repeat("a", 100))
+	}
+}
+
+func TestCompressedEncoder_Error(t *testing.T) {
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := NewCompressedEncoder(JsonCodec, "lz4").Encode(1)
+		assert.True(t, errors.Is(err, errUnsupportedCompression))
+	})
+
+	t.Run("level", func(t *testing.T) {
+		_, err := NewCompressedEncoder(JsonCodec, CompressionGzip, WithCompressLevel(100), WithCompressMinSize(0)).Encode(1)
+		assert.Error(t, err)
+	})
+
+	t.Run("inner", func(t *testing.T) {
+		_, err := NewCompressedEncoder(JsonCodec, CompressionGzip).Encode(make(chan int))
+		assert.Error(t, err)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		var encoder = NewStreamEncoder(MimeStream)
+		_, err := NewCompressedEncoder(encoder, CompressionGzip).Encode(&errorReader{})
+		assert.Error(t, err)
+		_, err = NewCompressedEncoder(encoder, CompressionGzip, WithCompressStream()).Encode(&errorReader{})
+		assert.Error(t, err)
+
+		var r = io.MultiReader(strings.NewReader(strings.Repeat("a", 10)), &errorReader{})
+		reader, err := NewCompressedEncoder(encoder, CompressionGzip, WithCompressStream(), WithCompressMinSize(5)).Encode(r)
+		assert.NoError(t, err)
+		_, err = io.ReadAll(reader)
+		assert.Error(t, err)
+	})
+
+	t.Run("close before read", func(t *testing.T) {
+		var encoder = NewCompressedEncoder(NewStreamEncoder(MimeStream), CompressionGzip, WithCompressStream(), WithCompressMinSize(1))
+		var body = io.NopCloser(strings.NewReader("hello"))
+		r, err := encoder.Encode(body)
+		assert.NoError(t, err)
+		assert.NoError(t, r.(io.Closer).Close())
+	})
+}
+
+type errorReader struct{}
+
+func (c *errorReader) Read(p []byte) (int, error) { return 0, errors.New("read error") }
+
+type compressedXml struct {
+	Text string
+}
+
+func TestCompressedEncoder_Client(t *testing.T) {
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var encoding = request.Header.Get("Content-Encoding")
+		writer.Header().Set("X-Content-Encoding", encoding)
+		writer.Write(decompress(t, encoding, request.Body))
+	})
+	go srv.ListenAndServe()
+	defer srv.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	var large = strings.Repeat("hasaki ", 500)
+	for _, stream := range []bool{false, true} {
+		var options []CompressOption
+		if stream {
+			options = append(options, WithCompressStream())
+		}
+		var request = Post("http://" + addr).SetEncoder(NewCompressedEncoder(XmlCodec, CompressionZstd, options...))
+
+		var resp = request.Send(compressedXml{Text: large})
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, resp.Header.Get("X-Content-Encoding"), "zstd")
+		p, _ := resp.ReadBody()
+		assert.Equal(t, string(p), "<compressedXml><Text>"+large+"</Text></compressedXml>")
+
+		resp = request.Send(compressedXml{Text: "a"})
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, resp.Header.Get("X-Content-Encoding"), "")
+	}
+}
@@ -3,6 +3,7 @@ package hasaki
 import (
 	"context"
 	"io"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -17,6 +18,10 @@ const (
 type (
 	BeforeFunc func(ctx context.Context, request *http.Request) (context.Context, error)
 	AfterFunc  func(ctx context.Context, response *http.Response) (context.Context, error)
+
+	// TransportMiddleware 包装HTTP客户端的Transport
+	// Wraps the transport of the HTTP client
+	TransportMiddleware func(next http.RoundTripper) http.RoundTripper
 )
 
 var (
@@ -47,10 +52,17 @@ func SetClient(c *Client) {
 
 type (
 	config struct {
-		BeforeFunc       BeforeFunc   // 请求前中间件
-		AfterFunc        AfterFunc    // 请求后中间件
-		HTTPClient       *http.Client // HTTP客户端
-		ReuseBodyEnabled bool         // 是否复用body
+		BeforeFunc       BeforeFunc            // 请求前中间件
+		AfterFunc        AfterFunc             // 请求后中间件
+		HTTPClient       *http.Client          // HTTP客户端
+		ReuseBodyEnabled bool                  // 是否复用body
+		Logger           *slog.Logger          // 结构化日志
+		LogConfig        *LogConfig            // 日志配置
+		Redactor         *Redactor             // 脱敏规则
+		HARRecorder      *HARRecorder          // HAR记录器
+		Transports       []TransportMiddleware // Transport中间件
+		MaxResponseSize  int64                 // 响应体最大字节数
+		BaseTransport    http.RoundTripper     // Transport中间件包装前的Transport
 	}
 
 	Option func(c *config)
@@ -88,6 +100,70 @@ func WithReuseBody() Option {
 	}
 }
 
+// WithLogger 设置结构化日志, 记录请求行, 响应状态, 耗时和body预览
+// Setting up the structured logger, which records the request line, response status, latency and body preview
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.Logger = logger
+	}
+}
+
+// WithLogConfig 设置日志级别和body预览长度
+// Setting the log levels and body preview limit
+func WithLogConfig(conf LogConfig) Option {
+	return func(c *config) {
+		c.LogConfig = &conf
+	}
+}
+
+// WithRedactor 设置脱敏规则, 同时作用于日志和CURL命令
+// Setting the redaction rules, applied to both logs and cURL commands
+func WithRedactor(redactor *Redactor) Option {
+	return func(c *config) {
+		c.Redactor = redactor
+	}
+}
+
+// WithHARRecorder 记录客户端发出的每一个请求, 调用HARRecorder.Flush写入HAR文件; 响应体读取完毕或者关闭后才会记录
+// Record every request of the client, call HARRecorder.Flush to write the HAR file; an entry is recorded once the response body is fully read or closed
+func WithHARRecorder(recorder *HARRecorder) Option {
+	return func(c *config) {
+		c.HARRecorder = recorder
+	}
+}
+
+// WithTransportMiddleware 设置Transport中间件, 包装WithHTTPClient设置的Transport; 第一个中间件位于最外层
+// Setting up transport middlewares which wrap the transport set by WithHTTPClient; the first middleware is the outermost
+func WithTransportMiddleware(middlewares ...TransportMiddleware) Option {
+	return func(c *config) {
+		c.Transports = append(c.Transports, middlewares...)
+	}
+}
+
+// WithFaultInjector 使用故障注入器包装WithHTTPClient设置的Transport
+// Wrapping the transport set by WithHTTPClient with the fault injector
+func WithFaultInjector(injector *FaultInjector) Option {
+	return WithTransportMiddleware(injector.Middleware())
+}
+
+// WithDecompression 声明支持的压缩算法(默认为gzip, deflate, br和zstd)并按Content-Encoding透明解压响应体, 读取body和解码前即完成解压.
+// 与手动设置Accept-Encoding不同, 它不会关闭Go自带的gzip解压后留下压缩数据; 不支持的编码保持原样.
+// Advertising the supported algorithms (gzip, deflate, br and zstd by default) and transparently decompressing the response body by Content-Encoding,
+// before the body is read or decoded. Unlike setting Accept-Encoding by hand, compressed bytes never reach Bind; unsupported encodings are left untouched.
+func WithDecompression(algos ...Compression) Option {
+	return WithTransportMiddleware(newDecompressor(algos...).middleware)
+}
+
+// WithMaxResponseSize 设置响应体的最大字节数, 超出时返回ErrBodyTooLarge; 小于等于0时不限制(默认).
+// 同时作用于Content-Length检查, WithReuseBody, ReadBody, Bind和直接读取Response.Body; 开启解压时按解压后的字节计数.
+// Setting the maximum size of the response body, ErrBodyTooLarge is returned when it is exceeded; no limit if less than or equal to 0 (default).
+// It applies to the Content-Length check, WithReuseBody, ReadBody, Bind and reading Response.Body directly; decompressed bytes are counted when decompression is enabled.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *config) {
+		c.MaxResponseSize = n
+	}
+}
+
 func withInitialize() Option {
 	return func(c *config) {
 
@@ -99,6 +175,15 @@ func withInitialize() Option {
 			c.AfterFunc = defaultAfterFunc
 		}
 
+		if c.LogConfig == nil {
+			var conf = DefaultLogConfig
+			c.LogConfig = &conf
+		}
+
+		if c.Redactor == nil {
+			c.Redactor = DefaultRedactor
+		}
+
 		if c.HTTPClient == nil {
 			c.HTTPClient = &http.Client{
 				Timeout: defaultTimeout,
@@ -108,6 +193,19 @@ func withInitialize() Option {
 				},
 			}
 		}
+
+		// 复制客户端, 避免修改用户传入的http.Client
+		c.BaseTransport = c.HTTPClient.Transport
+		if len(c.Transports) > 0 {
+			var client = *c.HTTPClient
+			if client.Transport == nil {
+				client.Transport = http.DefaultTransport
+			}
+			for i := len(c.Transports) - 1; i >= 0; i-- {
+				client.Transport = c.Transports[i](client.Transport)
+			}
+			c.HTTPClient = &client
+		}
 	}
 }
 
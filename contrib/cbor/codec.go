@@ -0,0 +1,130 @@
+package cbor
+
+import (
+	"bytes"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/lxzan/hasaki"
+	"github.com/lxzan/hasaki/internal"
+	"github.com/pkg/errors"
+	"github.com/valyala/bytebufferpool"
+	"io"
+)
+
+//go:generate go mod tidy
+
+// Codec 默认编解码器, time.Time编码为带标签0的RFC3339字符串
+// The default codec, time.Time is encoded as an RFC3339 string with tag 0
+var Codec = mustNewCodec()
+
+func init() {
+	hasaki.RegisterCodec(Codec)
+}
+
+type (
+	config struct {
+		canonical bool
+		timeMode  cbor.TimeMode
+		tags      cbor.TagSet
+	}
+
+	Option func(c *config)
+)
+
+// WithCanonical 使用RFC 8949 4.2.1定义的确定性编码(map按键排序, 整数和长度使用最短形式), 适用于需要签名的数据
+// Using the core deterministic encoding of RFC 8949 section 4.2.1 (sorted map keys, shortest integers and lengths), suitable for signed payloads
+func WithCanonical() Option {
+	return func(c *config) {
+		c.canonical = true
+	}
+}
+
+// WithTimeMode 设置time.Time的编码格式, 默认为cbor.TimeRFC3339Nano.
+// 字符串格式使用标签0, Unix时间戳格式使用标签1; 解码时标签可选.
+// Setting the encoding of time.Time, default is cbor.TimeRFC3339Nano.
+// String formats use tag 0 and Unix timestamps use tag 1; tags are optional when decoding.
+func WithTimeMode(mode cbor.TimeMode) Option {
+	return func(c *config) {
+		c.timeMode = mode
+	}
+}
+
+// WithTags 注册自定义标签
+// Registering custom tags
+func WithTags(tags cbor.TagSet) Option {
+	return func(c *config) {
+		c.tags = tags
+	}
+}
+
+type codec struct {
+	enc cbor.EncMode
+	dec cbor.DecMode
+}
+
+// NewCodec 创建CBOR编解码器
+// Creating a CBOR codec
+func NewCodec(options ...Option) (hasaki.Codec, error) {
+	var conf = &config{timeMode: cbor.TimeRFC3339Nano}
+	for _, f := range options {
+		f(conf)
+	}
+
+	var encOptions = cbor.EncOptions{}
+	if conf.canonical {
+		encOptions = cbor.CoreDetEncOptions()
+	}
+	encOptions.Time = conf.timeMode
+	encOptions.TimeTag = cbor.EncTagRequired
+	var decOptions = cbor.DecOptions{TimeTag: cbor.DecTagOptional}
+
+	var c = &codec{}
+	var err error
+	if conf.tags != nil {
+		c.enc, err = encOptions.EncModeWithTags(conf.tags)
+	} else {
+		c.enc, err = encOptions.EncMode()
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if conf.tags != nil {
+		c.dec, err = decOptions.DecModeWithTags(conf.tags)
+	} else {
+		c.dec, err = decOptions.DecMode()
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return c, nil
+}
+
+func mustNewCodec(options ...Option) hasaki.Codec {
+	c, err := NewCodec(options...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (c *codec) Encode(v any) (io.Reader, error) {
+	if v == nil {
+		return nil, nil
+	}
+	w := bytebufferpool.Get()
+	err := c.enc.NewEncoder(w).Encode(v)
+	r := &internal.CloserWrapper{B: w, R: bytes.NewReader(w.B)}
+	return r, errors.WithStack(err)
+}
+
+func (c *codec) ContentType() string {
+	return hasaki.MimeCbor
+}
+
+// Decode 解码; 开启WithReuseBody时用DecMode.Unmarshal解码缓存的响应体, 否则边读边解码
+// Decoding; the buffered body of WithReuseBody is decoded by DecMode.Unmarshal, otherwise the body is decoded while it is read
+func (c *codec) Decode(r io.Reader, v any) error {
+	if b, ok := r.(hasaki.BytesReadCloser); ok {
+		return errors.WithStack(c.dec.Unmarshal(b.Bytes(), v))
+	}
+	return errors.WithStack(c.dec.NewDecoder(r).Decode(v))
+}
@@ -0,0 +1,132 @@
+package cbor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/lxzan/hasaki"
+	"github.com/stretchr/testify/assert"
+)
+
+type reading struct {
+	Device string    `cbor:"device"`
+	Value  float64   `cbor:"value"`
+	At     time.Time `cbor:"at"`
+}
+
+func TestEncoder_ContentType(t *testing.T) {
+	assert.Equal(t, Codec.ContentType(), hasaki.MimeCbor)
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		var at = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		r, err := Codec.Encode(reading{Device: "d1", Value: 1.5, At: at})
+		assert.NoError(t, err)
+
+		var v reading
+		assert.NoError(t, Codec.Decode(r, &v))
+		assert.Equal(t, v.Device, "d1")
+		assert.True(t, v.At.Equal(at))
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		r, err := Codec.Encode(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, r)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Codec.Encode(make(chan int))
+		assert.Error(t, err)
+	})
+
+	t.Run("time tag", func(t *testing.T) {
+		var at = time.Unix(1700000000, 0).UTC()
+		r, _ := Codec.Encode(at)
+		p, _ := io.ReadAll(r)
+		assert.Equal(t, p[0], byte(0xc0)) // tag 0
+
+		c, err := NewCodec(WithTimeMode(cbor.TimeUnix))
+		assert.NoError(t, err)
+		r, _ = c.Encode(at)
+		p, _ = io.ReadAll(r)
+		assert.Equal(t, p[0], byte(0xc1)) // tag 1
+
+		var v time.Time
+		assert.NoError(t, Codec.Decode(bytes.NewReader(p), &v))
+		assert.True(t, v.Equal(at))
+	})
+}
+
+func TestCanonical(t *testing.T) {
+	c, err := NewCodec(WithCanonical())
+	assert.NoError(t, err)
+
+	var data = map[string]any{"z": 1, "a": 2, "mm": 3}
+	var first []byte
+	for i := 0; i < 10; i++ {
+		r, err := c.Encode(data)
+		assert.NoError(t, err)
+		p, _ := io.ReadAll(r)
+		if first == nil {
+			first = p
+		}
+		assert.Equal(t, p, first)
+	}
+	// 按照编码后的键排序: a, z, mm
+	assert.Equal(t, first, []byte{0xa3, 0x61, 'a', 0x02, 0x61, 'z', 0x01, 0x62, 'm', 'm', 0x03})
+}
+
+func TestWithTags(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	var tags = cbor.NewTagSet()
+	assert.NoError(t, tags.Add(cbor.TagOptions{EncTag: cbor.EncTagRequired, DecTag: cbor.DecTagRequired}, reflect.TypeOf(point{}), 1000))
+
+	c, err := NewCodec(WithTags(tags))
+	assert.NoError(t, err)
+	r, _ := c.Encode(point{X: 1, Y: 2})
+	p, _ := io.ReadAll(r)
+	assert.Equal(t, p[:3], []byte{0xd9, 0x03, 0xe8})
+
+	var v point
+	assert.NoError(t, c.Decode(bytes.NewReader(p), &v))
+	assert.Equal(t, v, point{X: 1, Y: 2})
+
+	_, err = NewCodec(WithTimeMode(cbor.TimeMode(100)))
+	assert.Error(t, err)
+}
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, request.Header.Get("Content-Type"), hasaki.MimeCbor)
+		var v reading
+		assert.NoError(t, Codec.Decode(request.Body, &v))
+		v.Value *= 2
+		r, _ := Codec.Encode(v)
+		io.Copy(writer, r)
+	}))
+	defer srv.Close()
+
+	cli, _ := hasaki.NewClient(hasaki.WithReuseBody())
+	var v reading
+	var err = cli.Post(srv.URL).SetEncoder(Codec).Send(reading{Device: "d1", Value: 1.5, At: time.Now()}).Bind(&v, Codec)
+	assert.NoError(t, err)
+	assert.Equal(t, v.Value, 3.0)
+}
+
+func TestRegister(t *testing.T) {
+	for _, contentType := range []string{hasaki.MimeCbor} {
+		codec, ok := hasaki.GetCodec(contentType)
+		assert.True(t, ok)
+		assert.Equal(t, codec, hasaki.Codec(Codec))
+	}
+}
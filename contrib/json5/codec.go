@@ -0,0 +1,48 @@
+package json5
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/lxzan/hasaki"
+	"github.com/lxzan/hasaki/internal"
+	"github.com/pkg/errors"
+	"github.com/titanous/json5"
+	"github.com/valyala/bytebufferpool"
+	"io"
+)
+
+//go:generate go mod tidy
+
+// Codec JSON5编解码器. 解码支持注释, 尾随逗号, 单引号字符串和不带引号的键; 编码输出标准JSON, 它同时也是合法的JSON5
+// The JSON5 codec. Decoding accepts comments, trailing commas, single quoted strings and unquoted keys;
+// encoding produces standard JSON, which is also valid JSON5
+var Codec = new(codec)
+
+func init() {
+	hasaki.RegisterCodec(Codec)
+}
+
+type codec struct{}
+
+func (c codec) Encode(v any) (io.Reader, error) {
+	if v == nil {
+		return nil, nil
+	}
+	w := bytebufferpool.Get()
+	err := json.NewEncoder(w).Encode(v)
+	r := &internal.CloserWrapper{B: w, R: bytes.NewReader(w.B)}
+	return r, errors.WithStack(err)
+}
+
+func (c codec) ContentType() string {
+	return hasaki.MimeJson5
+}
+
+// Decode 解码; 已缓存的响应体用json5.Unmarshal解码, 跳过json5.Decoder内部的读缓冲
+// Decoding; a buffered body is decoded by json5.Unmarshal, skipping the read buffer inside json5.Decoder
+func (c codec) Decode(r io.Reader, v any) error {
+	if b, ok := r.(hasaki.BytesReadCloser); ok {
+		return errors.WithStack(json5.Unmarshal(b.Bytes(), v))
+	}
+	return errors.WithStack(json5.NewDecoder(r).Decode(v))
+}
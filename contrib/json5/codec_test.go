@@ -0,0 +1,77 @@
+package json5
+
+import (
+	"github.com/lxzan/hasaki"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEncoder_ContentType(t *testing.T) {
+	assert.Equal(t, Codec.ContentType(), hasaki.MimeJson5)
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		var params = struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		}{Name: "aha"}
+
+		r, err := Codec.Encode(params)
+		assert.NoError(t, err)
+		p, _ := io.ReadAll(r)
+		assert.Equal(t, string(p), "{\"name\":\"aha\",\"age\":0}\n")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := Codec.Encode(nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Codec.Encode(make(chan int))
+		assert.Error(t, err)
+	})
+}
+
+func TestDecode(t *testing.T) {
+	var params = struct {
+		User struct {
+			Name string
+			Age  int
+		}
+	}{}
+
+	var text = `
+// comment
+{
+  user: {
+    name: 'caster',
+    age: 0x1,
+  },
+}
+`
+	var err = Codec.Decode(strings.NewReader(text), &params)
+	assert.NoError(t, err)
+	assert.Equal(t, params.User.Name, "caster")
+	assert.Equal(t, params.User.Age, 1)
+
+	t.Run("bytes", func(t *testing.T) {
+		r, _ := Codec.Encode(params)
+		params.User.Name = ""
+		assert.NoError(t, Codec.Decode(r, &params))
+		assert.Equal(t, params.User.Name, "caster")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		assert.Error(t, Codec.Decode(strings.NewReader("{user: "), &params))
+	})
+}
+
+func TestRegister(t *testing.T) {
+	codec, ok := hasaki.GetCodec(hasaki.MimeJson5)
+	assert.True(t, ok)
+	assert.Equal(t, codec, hasaki.Codec(Codec))
+}
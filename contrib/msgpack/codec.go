@@ -0,0 +1,61 @@
+package msgpack
+
+import (
+	"bytes"
+	"github.com/lxzan/hasaki"
+	"github.com/lxzan/hasaki/internal"
+	"github.com/pkg/errors"
+	"github.com/valyala/bytebufferpool"
+	"github.com/vmihailenco/msgpack/v5"
+	"io"
+)
+
+//go:generate go mod tidy
+
+// 没有msgpack标签时使用json标签
+const fallbackTag = "json"
+
+var Codec = new(codec)
+
+func init() {
+	hasaki.RegisterCodec(Codec, hasaki.MimeMsgpack, "application/x-msgpack", "application/vnd.msgpack")
+}
+
+type codec struct{}
+
+func (c codec) Encode(v any) (io.Reader, error) {
+	if v == nil {
+		return nil, nil
+	}
+	w := bytebufferpool.Get()
+	enc := msgpack.GetEncoder()
+	enc.Reset(w)
+	enc.SetCustomStructTag(fallbackTag)
+	err := enc.Encode(v)
+	msgpack.PutEncoder(enc)
+	r := &internal.CloserWrapper{B: w, R: bytes.NewReader(w.B)}
+	return r, errors.WithStack(err)
+}
+
+func (c codec) ContentType() string {
+	return hasaki.MimeMsgpack
+}
+
+// Decode 解码; 已缓存的响应体包装为bytes.Reader, 跳过bufio缓冲并预分配切片和map, 解码时仍会复制数据.
+// msgpack.Unmarshal无法设置json标签回退, 所以这里不直接调用它
+// Decoding; a buffered body is wrapped in a bytes.Reader to skip bufio and preallocate slices and maps, data is still copied while decoding.
+// msgpack.Unmarshal cannot fall back to json tags, so it is not called directly
+func (c codec) Decode(r io.Reader, v any) error {
+	dec := msgpack.GetDecoder()
+	b, buffered := r.(hasaki.BytesReadCloser)
+	if buffered {
+		r = bytes.NewReader(b.Bytes())
+	}
+	// Reset会清除解码选项, 选项需要在Reset之后设置
+	dec.Reset(r)
+	dec.SetCustomStructTag(fallbackTag)
+	dec.UsePreallocateValues(buffered)
+	err := dec.Decode(v)
+	msgpack.PutDecoder(dec)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,95 @@
+package msgpack
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lxzan/hasaki"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type user struct {
+	Name  string `msgpack:"name"`
+	Age   int    `json:"age"`
+	Email string `json:"email,omitempty"`
+}
+
+func TestEncoder_ContentType(t *testing.T) {
+	assert.Equal(t, Codec.ContentType(), hasaki.MimeMsgpack)
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		r, err := Codec.Encode(user{Name: "caster", Age: 1})
+		assert.NoError(t, err)
+		p, _ := io.ReadAll(r)
+
+		var m map[string]any
+		assert.NoError(t, msgpack.Unmarshal(p, &m))
+		assert.Equal(t, m, map[string]any{"name": "caster", "age": int8(1)})
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		r, err := Codec.Encode(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, r)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Codec.Encode(make(chan int))
+		assert.Error(t, err)
+	})
+}
+
+func TestDecode(t *testing.T) {
+	p, _ := msgpack.Marshal(map[string]any{"name": "caster", "age": 1})
+
+	t.Run("reader", func(t *testing.T) {
+		var v user
+		assert.NoError(t, Codec.Decode(bytes.NewReader(p), &v))
+		assert.Equal(t, v, user{Name: "caster", Age: 1})
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		var v user
+		r, _ := Codec.Encode(user{Name: "caster", Age: 1})
+		assert.NoError(t, Codec.Decode(r, &v))
+		assert.Equal(t, v, user{Name: "caster", Age: 1})
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var v user
+		assert.Error(t, Codec.Decode(bytes.NewReader([]byte{0xc1}), &v))
+	})
+}
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, request.Header.Get("Content-Type"), hasaki.MimeMsgpack)
+		var v user
+		assert.NoError(t, Codec.Decode(request.Body, &v))
+		v.Age++
+		writer.Header().Set("Content-Type", hasaki.MimeMsgpack)
+		r, _ := Codec.Encode(v)
+		io.Copy(writer, r)
+	}))
+	defer srv.Close()
+
+	cli, _ := hasaki.NewClient(hasaki.WithReuseBody())
+	var v user
+	var err = cli.Post(srv.URL).SetEncoder(Codec).Send(user{Name: "caster", Age: 1}).Bind(&v, Codec)
+	assert.NoError(t, err)
+	assert.Equal(t, v, user{Name: "caster", Age: 2})
+}
+
+func TestRegister(t *testing.T) {
+	for _, contentType := range []string{hasaki.MimeMsgpack, "application/x-msgpack", "application/vnd.msgpack"} {
+		codec, ok := hasaki.GetCodec(contentType)
+		assert.True(t, ok)
+		assert.Equal(t, codec, hasaki.Codec(Codec))
+	}
+}
@@ -0,0 +1,231 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lxzan/hasaki"
+	"github.com/lxzan/hasaki/internal"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//go:generate go mod tidy
+
+const instrumentationName = "github.com/lxzan/hasaki/contrib/otel"
+
+type (
+	config struct {
+		tracerProvider trace.TracerProvider
+		meterProvider  metric.MeterProvider
+		propagator     propagation.TextMapPropagator
+		spanName       func(r *http.Request) string
+	}
+
+	Option func(c *config)
+)
+
+// WithTracerProvider 设置TracerProvider, 默认使用全局的TracerProvider
+// Setting the TracerProvider, the global one is used by default
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider 设置MeterProvider, 默认使用全局的MeterProvider
+// Setting the MeterProvider, the global one is used by default
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// WithPropagator 设置上下文传播器, 默认注入W3C traceparent和baggage请求头
+// Setting the context propagator, W3C traceparent and baggage headers are injected by default
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+// WithSpanName 设置Span名称, 默认为请求方法
+// Setting the span name, which is the request method by default
+func WithSpanName(fn func(r *http.Request) string) Option {
+	return func(c *config) {
+		c.spanName = fn
+	}
+}
+
+// Instrument 返回一个客户端选项, 为每个请求创建Span并记录指标
+// Returns a client option that creates a span and records metrics for every request
+func Instrument(options ...Option) hasaki.Option {
+	return hasaki.WithTransportMiddleware(Middleware(options...))
+}
+
+// Middleware 返回一个Transport中间件, 为每个请求创建Span并记录指标
+// Returns a transport middleware that creates a span and records metrics for every request
+func Middleware(options ...Option) hasaki.TransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return NewTransport(next, options...)
+	}
+}
+
+// NewTransport 包装next, 为每个请求创建Span并记录指标
+// Wraps next, creating a span and recording metrics for every request
+func NewTransport(next http.RoundTripper, options ...Option) http.RoundTripper {
+	var conf = &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagator:     propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+		spanName:       func(r *http.Request) string { return r.Method },
+	}
+	for _, f := range options {
+		f(conf)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	var meter = conf.meterProvider.Meter(instrumentationName)
+	var t = &transport{
+		conf:   conf,
+		next:   next,
+		tracer: conf.tracerProvider.Tracer(instrumentationName),
+	}
+	t.duration, _ = meter.Float64Histogram(
+		semconv.HTTPClientRequestDurationName,
+		metric.WithUnit(semconv.HTTPClientRequestDurationUnit),
+		metric.WithDescription(semconv.HTTPClientRequestDurationDescription),
+	)
+	t.requestSize, _ = meter.Int64Histogram(
+		semconv.HTTPClientRequestBodySizeName,
+		metric.WithUnit(semconv.HTTPClientRequestBodySizeUnit),
+		metric.WithDescription(semconv.HTTPClientRequestBodySizeDescription),
+	)
+	t.responseSize, _ = meter.Int64Histogram(
+		semconv.HTTPClientResponseBodySizeName,
+		metric.WithUnit(semconv.HTTPClientResponseBodySizeUnit),
+		metric.WithDescription(semconv.HTTPClientResponseBodySizeDescription),
+	)
+	return t
+}
+
+type transport struct {
+	conf         *config
+	next         http.RoundTripper
+	tracer       trace.Tracer
+	duration     metric.Float64Histogram
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+}
+
+func (c *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	var startTime = time.Now()
+	var attrs = requestAttributes(r)
+	ctx, span := c.tracer.Start(r.Context(), c.conf.spanName(r),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, semconv.URLFull(redactURL(r)))...),
+	)
+
+	// RoundTripper不能修改传入的请求
+	r = r.Clone(ctx)
+	c.conf.propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+	if r.ContentLength > 0 {
+		span.SetAttributes(semconv.HTTPRequestBodySize(int(r.ContentLength)))
+	}
+
+	resp, err := c.next.RoundTrip(r)
+	if err != nil {
+		attrs = append(attrs, semconv.ErrorTypeKey.String(errorType(err)))
+		span.SetAttributes(attrs[len(attrs)-1])
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		c.record(ctx, r, startTime, attrs, -1)
+		return resp, err
+	}
+
+	attrs = append(attrs, semconv.HTTPResponseStatusCode(resp.StatusCode))
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		attrs = append(attrs, semconv.ErrorTypeKey.String(strconv.Itoa(resp.StatusCode)))
+		span.SetAttributes(attrs[len(attrs)-1])
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+
+	// Span在body读取完毕或者关闭时结束, 以包含传输耗时
+	resp.Body = internal.Track(resp.Body, func(n int64, readErr error) {
+		if readErr != nil {
+			span.RecordError(readErr)
+		}
+		span.SetAttributes(semconv.HTTPResponseBodySize(int(n)))
+		span.End()
+		c.record(ctx, r, startTime, attrs, n)
+	})
+	return resp, nil
+}
+
+func (c *transport) record(ctx context.Context, r *http.Request, startTime time.Time, attrs []attribute.KeyValue, responseSize int64) {
+	var set = metric.WithAttributes(attrs...)
+	c.duration.Record(ctx, time.Since(startTime).Seconds(), set)
+	if r.ContentLength >= 0 {
+		c.requestSize.Record(ctx, r.ContentLength, set)
+	}
+	if responseSize >= 0 {
+		c.responseSize.Record(ctx, responseSize, set)
+	}
+}
+
+func requestAttributes(r *http.Request) []attribute.KeyValue {
+	var attrs = []attribute.KeyValue{methodAttribute(r.Method)}
+	var host, port = r.URL.Hostname(), r.URL.Port()
+	if port == "" {
+		port = "80"
+		if r.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	if host != "" {
+		attrs = append(attrs, semconv.ServerAddress(host))
+	}
+	if v, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, semconv.ServerPort(v))
+	}
+	return attrs
+}
+
+func methodAttribute(method string) attribute.KeyValue {
+	switch method {
+	case http.MethodConnect, http.MethodDelete, http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodPatch, http.MethodPost, http.MethodPut, http.MethodTrace:
+		return semconv.HTTPRequestMethodKey.String(method)
+	default:
+		return semconv.HTTPRequestMethodOther
+	}
+}
+
+// redactURL 去掉URL中的用户名和密码
+func redactURL(r *http.Request) string {
+	if r.URL.User == nil {
+		return r.URL.String()
+	}
+	var u = *r.URL
+	u.User = nil
+	return u.String()
+}
+
+// errorType 使用与prometheus相同的错误分类, 无法归类时为_OTHER
+func errorType(err error) string {
+	if kind := internal.ErrorKind(err); kind != "other" {
+		return kind
+	}
+	return semconv.ErrorTypeOther.Value.AsString()
+}
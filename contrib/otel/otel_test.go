@@ -0,0 +1,142 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lxzan/hasaki"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestClient(t *testing.T) (*hasaki.Client, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	var recorder = tracetest.NewSpanRecorder()
+	var reader = sdkmetric.NewManualReader()
+	var cli, err = hasaki.NewClient(Instrument(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	))
+	assert.NoError(t, err)
+	return cli, recorder, reader
+}
+
+func attributeValue(attrs []attribute.KeyValue, key attribute.Key) attribute.Value {
+	for _, item := range attrs {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/500":
+			writer.WriteHeader(http.StatusInternalServerError)
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+			writer.WriteHeader(http.StatusOK)
+		default:
+			writer.Header().Set("traceparent", request.Header.Get("traceparent"))
+			writer.WriteHeader(http.StatusOK)
+			writer.Write([]byte("hello"))
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("ok", func(t *testing.T) {
+		var cli, recorder, reader = newTestClient(t)
+		var request *http.Request
+		var resp = cli.Post(srv.URL + "/greet").
+			SetBefore(func(ctx context.Context, r *http.Request) (context.Context, error) {
+				request = r
+				return ctx, nil
+			}).
+			Send(hasaki.Any{"name": "caster"})
+		assert.NoError(t, resp.Err())
+		assert.NotEmpty(t, resp.Header.Get("traceparent"))
+		assert.Empty(t, request.Header.Get("traceparent"))
+		assert.Equal(t, len(recorder.Ended()), 0)
+
+		p, err := resp.ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), "hello")
+
+		var spans = recorder.Ended()
+		assert.Equal(t, len(spans), 1)
+		var span = spans[0]
+		assert.Equal(t, span.Name(), http.MethodPost)
+		assert.Equal(t, span.SpanKind(), trace.SpanKindClient)
+		assert.Equal(t, span.Status().Code, codes.Unset)
+		assert.Equal(t, attributeValue(span.Attributes(), semconv.HTTPRequestMethodKey).AsString(), http.MethodPost)
+		assert.Equal(t, attributeValue(span.Attributes(), semconv.HTTPResponseStatusCodeKey).AsInt64(), int64(200))
+		assert.Equal(t, attributeValue(span.Attributes(), semconv.HTTPResponseBodySizeKey).AsInt64(), int64(5))
+		assert.Equal(t, attributeValue(span.Attributes(), semconv.URLFullKey).AsString(), srv.URL+"/greet")
+
+		var data metricdata.ResourceMetrics
+		assert.NoError(t, reader.Collect(context.Background(), &data))
+		var names = make(map[string]bool)
+		for _, item := range data.ScopeMetrics[0].Metrics {
+			names[item.Name] = true
+		}
+		assert.True(t, names[semconv.HTTPClientRequestDurationName])
+		assert.True(t, names[semconv.HTTPClientRequestBodySizeName])
+		assert.True(t, names[semconv.HTTPClientResponseBodySizeName])
+	})
+
+	t.Run("5xx", func(t *testing.T) {
+		var cli, recorder, _ = newTestClient(t)
+		var resp = cli.Get(srv.URL + "/500").Send(nil)
+		assert.NoError(t, resp.Err())
+		_, _ = resp.ReadBody()
+
+		var spans = recorder.Ended()
+		assert.Equal(t, len(spans), 1)
+		assert.Equal(t, spans[0].Status().Code, codes.Error)
+		assert.Equal(t, attributeValue(spans[0].Attributes(), semconv.ErrorTypeKey).AsString(), "500")
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		var cli, recorder, _ = newTestClient(t)
+		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		var resp = cli.Get(srv.URL + "/slow").SetContext(ctx).Send(nil)
+		assert.Error(t, resp.Err())
+
+		var spans = recorder.Ended()
+		assert.Equal(t, len(spans), 1)
+		assert.Equal(t, spans[0].Status().Code, codes.Error)
+		assert.Equal(t, len(spans[0].Events()), 1)
+		assert.Equal(t, attributeValue(spans[0].Attributes(), semconv.ErrorTypeKey).AsString(), "timeout")
+	})
+
+	t.Run("parent span", func(t *testing.T) {
+		var recorder = tracetest.NewSpanRecorder()
+		var provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		var cli, _ = hasaki.NewClient(Instrument(WithTracerProvider(provider), WithSpanName(func(r *http.Request) string {
+			return "HTTP " + r.Method
+		})))
+
+		ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+		var resp = cli.Get(srv.URL).SetContext(ctx).Send(nil)
+		_, _ = resp.ReadBody()
+		parent.End()
+
+		var spans = recorder.Ended()
+		assert.Equal(t, len(spans), 2)
+		assert.Equal(t, spans[0].Name(), "HTTP GET")
+		assert.Equal(t, spans[0].Parent().SpanID(), parent.SpanContext().SpanID())
+		assert.Contains(t, resp.Header.Get("traceparent"), parent.SpanContext().TraceID().String())
+	})
+}
@@ -18,6 +18,11 @@ var (
 	Codec = new(codec)
 )
 
+// JSONCodec的Content-Type与hasaki.JsonCodec相同, 不自动注册, 以免覆盖application/json
+func init() {
+	hasaki.RegisterCodec(Codec, hasaki.MimeProtoBuf, "application/protobuf")
+}
+
 type codec struct{}
 
 func (c codec) Encode(v any) (io.Reader, error) {
@@ -28,8 +33,11 @@ func (c codec) Encode(v any) (io.Reader, error) {
 	if !ok {
 		return nil, errors.WithStack(errDataType)
 	}
-	p, err := proto.Marshal(message)
-	return bytes.NewReader(p), errors.WithStack(err)
+	w := bytebufferpool.Get()
+	p, err := proto.MarshalOptions{}.MarshalAppend(w.B[:0], message)
+	w.B = p
+	r := &internal.CloserWrapper{B: w, R: bytes.NewReader(w.B)}
+	return r, errors.WithStack(err)
 }
 
 func (c codec) ContentType() string {
@@ -41,14 +49,21 @@ func (c codec) Decode(r io.Reader, v any) error {
 	if !ok {
 		return errors.WithStack(errDataType)
 	}
+	return decode(r, func(p []byte) error { return proto.Unmarshal(p, message) })
+}
+
+// decode 读取r的全部内容并调用unmarshal; r为hasaki.BytesReadCloser时不再复制
+func decode(r io.Reader, unmarshal func(p []byte) error) error {
 	if br, ok := r.(hasaki.BytesReadCloser); ok {
-		return errors.WithStack(proto.Unmarshal(br.Bytes(), message))
+		return errors.WithStack(unmarshal(br.Bytes()))
 	}
 	var w = bytebufferpool.Get()
 	var temp = internal.GetBuffer()
-	_, _ = io.CopyBuffer(w, r, temp.Bytes()[:internal.BufferSize])
-	var err = proto.Unmarshal(w.B, message)
+	_, err := io.CopyBuffer(w, r, temp.Bytes()[:internal.BufferSize])
 	internal.PutBuffer(temp)
+	if err == nil {
+		err = unmarshal(w.B)
+	}
 	bytebufferpool.Put(w)
 	return errors.WithStack(err)
 }
@@ -81,3 +81,25 @@ func TestDecode(t *testing.T) {
 		assert.True(t, errors.Is(err, errDataType))
 	})
 }
+
+type errReader struct{}
+
+func (c errReader) Read(p []byte) (n int, err error) {
+	return 0, errors.New("test")
+}
+
+func TestDecode_ReadError(t *testing.T) {
+	var res = &internal.HelloRequest{}
+	assert.Error(t, Codec.Decode(errReader{}, res))
+}
+
+func TestRegister(t *testing.T) {
+	for _, contentType := range []string{hasaki.MimeProtoBuf, "application/protobuf"} {
+		codec, ok := hasaki.GetCodec(contentType)
+		assert.True(t, ok)
+		assert.Equal(t, codec, hasaki.Codec(Codec))
+	}
+
+	codec, _ := hasaki.GetCodec(hasaki.MimeJson)
+	assert.Equal(t, codec, hasaki.Codec(hasaki.JsonCodec))
+}
@@ -0,0 +1,14 @@
+package pb
+
+import (
+	"github.com/lxzan/hasaki"
+)
+
+// NewGzipEncoder 使用gzip压缩的protobuf编码器, Content-Type为application/x-protobuf, Content-Encoding为gzip.
+// 默认小于1KB的消息不压缩, 可以通过hasaki.WithCompressMinSize等选项调整; gzip压缩的响应开启hasaki.WithDecompression后使用Codec解码.
+// A protobuf encoder compressed with gzip; the Content-Type is application/x-protobuf and the Content-Encoding is gzip.
+// Messages below 1KB are sent uncompressed by default, tune it with options such as hasaki.WithCompressMinSize;
+// gzip responses are decoded by Codec once hasaki.WithDecompression is enabled.
+func NewGzipEncoder(options ...hasaki.CompressOption) hasaki.Encoder {
+	return hasaki.NewCompressedEncoder(Codec, hasaki.CompressionGzip, options...)
+}
@@ -0,0 +1,75 @@
+package pb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lxzan/hasaki"
+	"github.com/lxzan/hasaki/contrib/pb/internal"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNewGzipEncoder(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var body io.Reader = request.Body
+		writer.Header().Set("X-Content-Encoding", request.Header.Get("Content-Encoding"))
+		if request.Header.Get("Content-Encoding") == "gzip" {
+			gr, err := gzip.NewReader(request.Body)
+			if err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = gr
+		}
+		p, _ := io.ReadAll(body)
+		var req = &internal.HelloRequest{}
+		if err := proto.Unmarshal(p, req); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		p, _ = proto.Marshal(&internal.HelloRequest{Name: "hello " + req.Name, Age: req.Age + 1})
+		var buf = bytes.NewBuffer(nil)
+		var gw = gzip.NewWriter(buf)
+		gw.Write(p)
+		gw.Close()
+		writer.Header().Set("Content-Type", hasaki.MimeProtoBuf)
+		writer.Header().Set("Content-Encoding", "gzip")
+		writer.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	cli, _ := hasaki.NewClient(hasaki.WithDecompression())
+
+	t.Run("compressed", func(t *testing.T) {
+		var resp = cli.Post(srv.URL).
+			SetEncoder(NewGzipEncoder(hasaki.WithCompressMinSize(0))).
+			Send(&internal.HelloRequest{Name: "caster"})
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, resp.StatusCode, http.StatusOK)
+		assert.Equal(t, resp.Header.Get("X-Content-Encoding"), "gzip")
+
+		var reply = &internal.HelloRequest{}
+		assert.NoError(t, resp.Bind(reply, Codec))
+		assert.Equal(t, reply.Name, "hello caster")
+	})
+
+	t.Run("below threshold", func(t *testing.T) {
+		var resp = cli.Post(srv.URL).SetEncoder(NewGzipEncoder()).Send(&internal.HelloRequest{Name: "caster"})
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, resp.Header.Get("X-Content-Encoding"), "")
+
+		var reply = &internal.HelloRequest{}
+		assert.NoError(t, resp.Bind(reply, Codec))
+		assert.Equal(t, reply.Name, "hello caster")
+	})
+
+	t.Run("content type", func(t *testing.T) {
+		assert.Equal(t, NewGzipEncoder().ContentType(), hasaki.MimeProtoBuf)
+	})
+}
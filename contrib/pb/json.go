@@ -0,0 +1,90 @@
+package pb
+
+import (
+	"bytes"
+	"github.com/lxzan/hasaki"
+	"github.com/lxzan/hasaki/internal"
+	"github.com/pkg/errors"
+	"github.com/valyala/bytebufferpool"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"io"
+)
+
+// JSONCodec 默认的protobuf JSON编解码器, 字段名使用lowerCamelCase; 它与hasaki.JsonCodec共用application/json, 因此不会自动注册
+// The default protobuf JSON codec, field names are lowerCamelCase; it shares application/json with hasaki.JsonCodec and is therefore not registered automatically
+var JSONCodec = NewJSONCodec()
+
+type (
+	config struct {
+		marshal   protojson.MarshalOptions
+		unmarshal protojson.UnmarshalOptions
+	}
+
+	Option func(c *config)
+)
+
+// WithProtoNames 使用proto文件中定义的字段名, 而不是lowerCamelCase
+// Using the field names defined in the proto file instead of lowerCamelCase
+func WithProtoNames() Option {
+	return func(c *config) {
+		c.marshal.UseProtoNames = true
+	}
+}
+
+// WithEmitUnpopulated 输出未赋值的字段
+// Emitting unpopulated fields
+func WithEmitUnpopulated() Option {
+	return func(c *config) {
+		c.marshal.EmitUnpopulated = true
+	}
+}
+
+// WithDiscardUnknown 解码时忽略未知字段, 默认返回错误
+// Discarding unknown fields when decoding, an error is returned by default
+func WithDiscardUnknown() Option {
+	return func(c *config) {
+		c.unmarshal.DiscardUnknown = true
+	}
+}
+
+type jsonCodec struct {
+	conf *config
+}
+
+// NewJSONCodec 创建使用protojson的编解码器
+// Creating a codec using protojson
+func NewJSONCodec(options ...Option) hasaki.Codec {
+	var conf = &config{}
+	for _, f := range options {
+		f(conf)
+	}
+	return &jsonCodec{conf: conf}
+}
+
+func (c *jsonCodec) Encode(v any) (io.Reader, error) {
+	if v == nil {
+		return nil, nil
+	}
+	message, ok := v.(proto.Message)
+	if !ok {
+		return nil, errors.WithStack(errDataType)
+	}
+	w := bytebufferpool.Get()
+	p, err := c.conf.marshal.MarshalAppend(w.B[:0], message)
+	w.B = p
+	r := &internal.CloserWrapper{B: w, R: bytes.NewReader(w.B)}
+	return r, errors.WithStack(err)
+}
+
+func (c *jsonCodec) ContentType() string {
+	return hasaki.MimeJson
+}
+
+func (c *jsonCodec) Decode(r io.Reader, v any) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return errors.WithStack(errDataType)
+	}
+	return decode(r, func(p []byte) error { return c.conf.unmarshal.Unmarshal(p, message) })
+}
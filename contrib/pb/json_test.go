@@ -0,0 +1,103 @@
+package pb
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lxzan/hasaki"
+	"github.com/lxzan/hasaki/contrib/pb/internal"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONCodec_Encode(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		r, err := JSONCodec.Encode(&internal.HelloRequest{Name: "caster", Age: 1})
+		assert.NoError(t, err)
+		p, _ := io.ReadAll(r)
+		assert.JSONEq(t, string(p), `{"name":"caster","age":1}`)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		r, err := JSONCodec.Encode(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, r)
+	})
+
+	t.Run("unexpected type", func(t *testing.T) {
+		_, err := JSONCodec.Encode(struct{}{})
+		assert.True(t, errors.Is(err, errDataType))
+	})
+
+	t.Run("emit unpopulated", func(t *testing.T) {
+		r, err := NewJSONCodec(WithEmitUnpopulated()).Encode(&internal.HelloRequest{Name: "caster"})
+		assert.NoError(t, err)
+		p, _ := io.ReadAll(r)
+		assert.JSONEq(t, string(p), `{"name":"caster","age":0}`)
+	})
+
+	t.Run("proto names", func(t *testing.T) {
+		r, err := NewJSONCodec(WithProtoNames()).Encode(&internal.HelloRequest{Name: "caster"})
+		assert.NoError(t, err)
+		p, _ := io.ReadAll(r)
+		assert.JSONEq(t, string(p), `{"name":"caster"}`)
+	})
+}
+
+func TestJSONCodec_ContentType(t *testing.T) {
+	assert.Equal(t, JSONCodec.ContentType(), hasaki.MimeJson)
+}
+
+func TestJSONCodec_Decode(t *testing.T) {
+	t.Run("reader", func(t *testing.T) {
+		var res = &internal.HelloRequest{}
+		assert.NoError(t, JSONCodec.Decode(bytes.NewReader([]byte(`{"name":"caster","age":1}`)), res))
+		assert.Equal(t, res.Name, "caster")
+		assert.Equal(t, res.Age, int32(1))
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		r, _ := JSONCodec.Encode(&internal.HelloRequest{Name: "caster", Age: 1})
+		var res = &internal.HelloRequest{}
+		assert.NoError(t, JSONCodec.Decode(r, res))
+		assert.Equal(t, res.Name, "caster")
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		var p = []byte(`{"name":"caster","extra":1}`)
+		var res = &internal.HelloRequest{}
+		assert.Error(t, JSONCodec.Decode(bytes.NewReader(p), res))
+		assert.NoError(t, NewJSONCodec(WithDiscardUnknown()).Decode(bytes.NewReader(p), res))
+		assert.Equal(t, res.Name, "caster")
+	})
+
+	t.Run("unexpected type", func(t *testing.T) {
+		var res = struct{ Name string }{}
+		var err = JSONCodec.Decode(bytes.NewReader([]byte(`{}`)), &res)
+		assert.True(t, errors.Is(err, errDataType))
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		assert.Error(t, JSONCodec.Decode(errReader{}, &internal.HelloRequest{}))
+	})
+}
+
+func TestJSONCodec_Client(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, request.Header.Get("Content-Type"), hasaki.MimeJson)
+		var req = &internal.HelloRequest{}
+		assert.NoError(t, JSONCodec.Decode(request.Body, req))
+		req.Age++
+		r, _ := JSONCodec.Encode(req)
+		io.Copy(writer, r)
+	}))
+	defer srv.Close()
+
+	var res = &internal.HelloRequest{}
+	var err = hasaki.Post(srv.URL).SetEncoder(JSONCodec).Send(&internal.HelloRequest{Name: "caster", Age: 1}).Bind(res, JSONCodec)
+	assert.NoError(t, err)
+	assert.Equal(t, res.Age, int32(2))
+}
@@ -0,0 +1,203 @@
+package prometheus
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lxzan/hasaki"
+	"github.com/lxzan/hasaki/internal"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+//go:generate go mod tidy
+
+const (
+	labelMethod = "method"
+	labelHost   = "host"
+	labelStatus = "status"
+	labelError  = "error"
+)
+
+type (
+	config struct {
+		namespace   string
+		subsystem   string
+		constLabels prom.Labels
+		buckets     []float64
+		hostLabel   func(r *http.Request) string
+	}
+
+	Option func(c *config)
+)
+
+// WithNamespace 设置指标的命名空间, 默认为hasaki
+// Setting the namespace of metrics, default is hasaki
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithSubsystem 设置指标的子系统, 用于区分多个客户端
+// Setting the subsystem of metrics, useful for distinguishing multiple clients
+func WithSubsystem(subsystem string) Option {
+	return func(c *config) {
+		c.subsystem = subsystem
+	}
+}
+
+// WithConstLabels 设置常量标签
+// Setting the constant labels
+func WithConstLabels(labels prom.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// WithBuckets 设置耗时直方图的桶, 默认为prometheus.DefBuckets
+// Setting the buckets of the latency histogram, default is prometheus.DefBuckets
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// WithHostLabel 设置host标签的取值, 默认为URL中的主机名.
+// 请求的主机不固定时(例如按租户拼接子域名), 应当返回有限的取值以避免标签基数爆炸.
+// Setting the value of the host label, default is the hostname of the URL.
+// When hosts are unbounded (e.g. per-tenant subdomains), return a bounded value to avoid a cardinality blow-up.
+func WithHostLabel(fn func(r *http.Request) string) Option {
+	return func(c *config) {
+		c.hostLabel = fn
+	}
+}
+
+// Collector 记录hasaki客户端的请求指标, 实现了prometheus.Collector
+// Records request metrics of hasaki clients, implementing prometheus.Collector
+type Collector struct {
+	conf     *config
+	requests *prom.CounterVec
+	duration *prom.HistogramVec
+	inFlight *prom.GaugeVec
+}
+
+// NewCollector 创建指标收集器
+// Creating a metrics collector
+func NewCollector(options ...Option) *Collector {
+	var conf = &config{
+		namespace: "hasaki",
+		buckets:   prom.DefBuckets,
+		hostLabel: func(r *http.Request) string { return r.URL.Hostname() },
+	}
+	for _, f := range options {
+		f(conf)
+	}
+
+	return &Collector{
+		conf: conf,
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace:   conf.namespace,
+			Subsystem:   conf.subsystem,
+			Name:        "client_requests_total",
+			Help:        "Total number of HTTP requests sent by the client.",
+			ConstLabels: conf.constLabels,
+		}, []string{labelMethod, labelHost, labelStatus, labelError}),
+		duration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace:   conf.namespace,
+			Subsystem:   conf.subsystem,
+			Name:        "client_request_duration_seconds",
+			Help:        "Duration of HTTP requests from sending to the response body being read or closed.",
+			ConstLabels: conf.constLabels,
+			Buckets:     conf.buckets,
+		}, []string{labelMethod, labelHost, labelStatus}),
+		inFlight: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace:   conf.namespace,
+			Subsystem:   conf.subsystem,
+			Name:        "client_requests_in_flight",
+			Help:        "Number of HTTP requests currently in flight.",
+			ConstLabels: conf.constLabels,
+		}, []string{labelMethod, labelHost}),
+	}
+}
+
+// Describe 实现prometheus.Collector
+// Implementing prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+	c.inFlight.Describe(ch)
+}
+
+// Collect 实现prometheus.Collector
+// Implementing prometheus.Collector
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+	c.inFlight.Collect(ch)
+}
+
+// Instrument 返回一个客户端选项, 记录该客户端所有请求的指标
+// Returns a client option that records metrics of every request of the client
+func (c *Collector) Instrument() hasaki.Option {
+	return hasaki.WithTransportMiddleware(c.Middleware())
+}
+
+// Middleware 返回一个Transport中间件, 记录每个请求的指标
+// Returns a transport middleware that records metrics of every request
+func (c *Collector) Middleware() hasaki.TransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return c.NewTransport(next)
+	}
+}
+
+// NewTransport 包装next, 记录每个请求的指标
+// Wraps next, recording metrics of every request
+func (c *Collector) NewTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{collector: c, next: next}
+}
+
+type transport struct {
+	collector *Collector
+	next      http.RoundTripper
+}
+
+func (c *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	var startTime = time.Now()
+	var method, host = r.Method, c.collector.conf.hostLabel(r)
+	var inFlight = c.collector.inFlight.WithLabelValues(method, host)
+	inFlight.Inc()
+
+	var finish = func(status, errKind string) {
+		inFlight.Dec()
+		c.collector.requests.WithLabelValues(method, host, status, errKind).Inc()
+		c.collector.duration.WithLabelValues(method, host, status).Observe(time.Since(startTime).Seconds())
+	}
+
+	resp, err := c.next.RoundTrip(r)
+	if err != nil {
+		finish("error", internal.ErrorKind(err))
+		return resp, err
+	}
+
+	// 在body读取完毕或者关闭时结束计时, 以包含传输耗时
+	var status = statusClass(resp.StatusCode)
+	resp.Body = internal.Track(resp.Body, func(n int64, err error) {
+		var errKind = ""
+		if err != nil {
+			errKind = internal.ErrorKind(err)
+		}
+		finish(status, errKind)
+	})
+	return resp, nil
+}
+
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
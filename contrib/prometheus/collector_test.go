@@ -0,0 +1,90 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lxzan/hasaki"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/500":
+			writer.WriteHeader(http.StatusInternalServerError)
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+			writer.WriteHeader(http.StatusOK)
+		default:
+			writer.WriteHeader(http.StatusOK)
+			writer.Write([]byte("hello"))
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("ok", func(t *testing.T) {
+		var collector = NewCollector()
+		var registry = prom.NewRegistry()
+		assert.NoError(t, registry.Register(collector))
+		var cli, _ = hasaki.NewClient(collector.Instrument())
+
+		var resp = cli.Get(srv.URL).Send(nil)
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, testutil.ToFloat64(collector.inFlight.WithLabelValues(http.MethodGet, "127.0.0.1")), float64(1))
+		assert.Equal(t, testutil.ToFloat64(collector.requests.WithLabelValues(http.MethodGet, "127.0.0.1", "2xx", "")), float64(0))
+
+		_, err := resp.ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, testutil.ToFloat64(collector.inFlight.WithLabelValues(http.MethodGet, "127.0.0.1")), float64(0))
+		assert.Equal(t, testutil.ToFloat64(collector.requests.WithLabelValues(http.MethodGet, "127.0.0.1", "2xx", "")), float64(1))
+
+		_, _ = cli.Post(srv.URL + "/500").Send(nil).ReadBody()
+		assert.Equal(t, testutil.ToFloat64(collector.requests.WithLabelValues(http.MethodPost, "127.0.0.1", "5xx", "")), float64(1))
+
+		count, err := testutil.GatherAndCount(registry, "hasaki_client_request_duration_seconds")
+		assert.NoError(t, err)
+		assert.Equal(t, count, 2)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var collector = NewCollector()
+		var cli, _ = hasaki.NewClient(collector.Instrument())
+		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		var resp = cli.Get(srv.URL + "/slow").SetContext(ctx).Send(nil)
+		assert.Error(t, resp.Err())
+		assert.Equal(t, testutil.ToFloat64(collector.requests.WithLabelValues(http.MethodGet, "127.0.0.1", "error", "timeout")), float64(1))
+		assert.Equal(t, testutil.ToFloat64(collector.inFlight.WithLabelValues(http.MethodGet, "127.0.0.1")), float64(0))
+	})
+
+	t.Run("options", func(t *testing.T) {
+		var collector = NewCollector(
+			WithNamespace("app"),
+			WithSubsystem("github"),
+			WithConstLabels(prom.Labels{"client": "github"}),
+			WithBuckets([]float64{0.1, 1}),
+			WithHostLabel(func(r *http.Request) string { return "api" }),
+		)
+		var cli, _ = hasaki.NewClient(collector.Instrument())
+		_, _ = cli.Get(srv.URL).Send(nil).ReadBody()
+
+		var expected = `
+# HELP app_github_client_requests_total Total number of HTTP requests sent by the client.
+# TYPE app_github_client_requests_total counter
+app_github_client_requests_total{client="github",error="",host="api",method="GET",status="2xx"} 1
+`
+		assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "app_github_client_requests_total"))
+	})
+}
+
+func TestStatusClass(t *testing.T) {
+	assert.Equal(t, statusClass(404), "4xx")
+	assert.Equal(t, statusClass(0), "unknown")
+}
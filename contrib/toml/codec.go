@@ -0,0 +1,44 @@
+package toml
+
+import (
+	"bytes"
+	"github.com/lxzan/hasaki"
+	"github.com/lxzan/hasaki/internal"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+	"github.com/valyala/bytebufferpool"
+	"io"
+)
+
+//go:generate go mod tidy
+
+var Codec = new(codec)
+
+func init() {
+	hasaki.RegisterCodec(Codec, hasaki.MimeToml, "application/x-toml")
+}
+
+type codec struct{}
+
+func (c codec) Encode(v any) (io.Reader, error) {
+	if v == nil {
+		return nil, nil
+	}
+	w := bytebufferpool.Get()
+	err := toml.NewEncoder(w).Encode(v)
+	r := &internal.CloserWrapper{B: w, R: bytes.NewReader(w.B)}
+	return r, errors.WithStack(err)
+}
+
+func (c codec) ContentType() string {
+	return hasaki.MimeToml
+}
+
+// Decode 解码; toml.Decoder会先把输入全部读入内存, 已缓存的响应体直接交给toml.Unmarshal
+// Decoding; toml.Decoder reads the whole input into memory first, so a buffered body is passed to toml.Unmarshal as is
+func (c codec) Decode(r io.Reader, v any) error {
+	if b, ok := r.(hasaki.BytesReadCloser); ok {
+		return errors.WithStack(toml.Unmarshal(b.Bytes(), v))
+	}
+	return errors.WithStack(toml.NewDecoder(r).Decode(v))
+}
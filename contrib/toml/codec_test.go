@@ -0,0 +1,74 @@
+package toml
+
+import (
+	"github.com/lxzan/hasaki"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEncoder_ContentType(t *testing.T) {
+	assert.Equal(t, Codec.ContentType(), hasaki.MimeToml)
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		var params = struct {
+			Name string `toml:"name"`
+			Age  int    `toml:"age"`
+		}{Name: "aha"}
+
+		r, err := Codec.Encode(params)
+		assert.NoError(t, err)
+		p, _ := io.ReadAll(r)
+		assert.Equal(t, string(p), "name = 'aha'\nage = 0\n")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := Codec.Encode(nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Codec.Encode(make(chan int))
+		assert.Error(t, err)
+	})
+}
+
+func TestDecode(t *testing.T) {
+	var params = struct {
+		User struct {
+			Name string
+			Age  int
+		}
+	}{}
+
+	var text = `
+[user]
+name = "caster"
+age = 1
+`
+	var err = Codec.Decode(strings.NewReader(text), &params)
+	assert.NoError(t, err)
+	assert.Equal(t, params.User.Name, "caster")
+
+	t.Run("bytes", func(t *testing.T) {
+		r, _ := Codec.Encode(params)
+		params.User.Name = ""
+		assert.NoError(t, Codec.Decode(r, &params))
+		assert.Equal(t, params.User.Name, "caster")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		assert.Error(t, Codec.Decode(strings.NewReader("user = "), &params))
+	})
+}
+
+func TestRegister(t *testing.T) {
+	for _, contentType := range []string{hasaki.MimeToml, "application/x-toml; charset=utf-8"} {
+		codec, ok := hasaki.GetCodec(contentType)
+		assert.True(t, ok)
+		assert.Equal(t, codec, hasaki.Codec(Codec))
+	}
+}
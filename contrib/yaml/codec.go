@@ -14,6 +14,10 @@ import (
 
 var Codec = new(codec)
 
+func init() {
+	hasaki.RegisterCodec(Codec, hasaki.MimeYaml, "application/yaml", "text/yaml")
+}
+
 type codec struct{}
 
 func (c codec) Encode(v any) (io.Reader, error) {
@@ -2,7 +2,12 @@ package yaml
 
 import (
 	"github.com/lxzan/hasaki"
+	"github.com/lxzan/hasaki/hasakitest"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -45,3 +50,37 @@ user:
 	assert.NoError(t, err)
 	assert.Equal(t, params.User.Name, "caster")
 }
+
+func TestRegister(t *testing.T) {
+	for _, contentType := range []string{hasaki.MimeYaml, "application/yaml", "text/yaml; charset=utf-8"} {
+		codec, ok := hasaki.GetCodec(contentType)
+		assert.True(t, ok)
+		assert.Equal(t, codec, hasaki.Codec(Codec))
+	}
+}
+
+func TestRecorder_Cassette(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", hasaki.MimeJson)
+		writer.Write([]byte(`{"name":"caster"}`))
+	}))
+	defer srv.Close()
+
+	var filename = filepath.Join(t.TempDir(), "cassette.yaml")
+	rec, err := hasakitest.NewRecorder(filename, hasakitest.WithCassetteCodec(Codec))
+	assert.NoError(t, err)
+	cli, _ := rec.NewClient()
+	assert.NoError(t, cli.Get(srv.URL).Send(nil).Err())
+	assert.NoError(t, rec.Save())
+
+	content, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(content), "interactions:"))
+
+	rec, err = hasakitest.NewRecorder(filename, hasakitest.WithMode(hasakitest.ModeReplay), hasakitest.WithCassetteCodec(Codec))
+	assert.NoError(t, err)
+	cli, _ = rec.NewClient()
+	p, err := cli.Get(srv.URL).Send(nil).ReadBody()
+	assert.NoError(t, err)
+	assert.Equal(t, string(p), `{"name":"caster"}`)
+}
@@ -1,6 +1,6 @@
 module github.com/lxzan/hasaki/contrib/yaml
 
-go 1.18
+go 1.21
 
 require (
 	github.com/lxzan/hasaki v0.0.0-00010101000000-000000000000
@@ -11,11 +11,14 @@ require (
 )
 
 require (
+	github.com/andybalholm/brotli v1.1.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
 )
 
 replace github.com/lxzan/hasaki => ../../
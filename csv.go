@@ -0,0 +1,490 @@
+package hasaki
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lxzan/hasaki/internal"
+	"github.com/pkg/errors"
+	"github.com/valyala/bytebufferpool"
+)
+
+const csvTagName = "csv"
+
+var (
+	// CsvCodec 逗号分隔的CSV编解码器
+	// The comma separated CSV codec
+	CsvCodec = NewCsvCodec()
+
+	// TsvCodec 制表符分隔的TSV编解码器
+	// The tab separated TSV codec
+	TsvCodec = NewCsvCodec(WithCsvComma('\t'))
+
+	csvFieldsCache = sync.Map{}
+)
+
+type (
+	csvConfig struct {
+		comma      rune
+		comment    rune
+		lazyQuotes bool
+	}
+
+	// CsvOption CSV编解码器配置
+	// CSV codec option
+	CsvOption func(c *csvConfig)
+)
+
+// WithCsvComma 设置分隔符, 默认为逗号. 分隔符为制表符时Content-Type为text/tab-separated-values
+// Setting the field delimiter, default is comma. The Content-Type is text/tab-separated-values when it is a tab
+func WithCsvComma(comma rune) CsvOption {
+	return func(c *csvConfig) {
+		c.comma = comma
+	}
+}
+
+// WithCsvComment 设置注释符, 以其开头的行在解码时被忽略
+// Setting the comment character, lines beginning with it are ignored when decoding
+func WithCsvComment(comment rune) CsvOption {
+	return func(c *csvConfig) {
+		c.comment = comment
+	}
+}
+
+// WithCsvLazyQuotes 解码时允许不规范的引号
+// Allowing non-standard quotes when decoding
+func WithCsvLazyQuotes() CsvOption {
+	return func(c *csvConfig) {
+		c.lazyQuotes = true
+	}
+}
+
+func newCsvConfig(options ...CsvOption) *csvConfig {
+	var conf = &csvConfig{comma: ','}
+	for _, f := range options {
+		f(conf)
+	}
+	return conf
+}
+
+func (c *csvConfig) newReader(r io.Reader) *csv.Reader {
+	var reader = csv.NewReader(r)
+	reader.Comma = c.comma
+	reader.Comment = c.comment
+	reader.LazyQuotes = c.lazyQuotes
+	return reader
+}
+
+type csvCodec struct {
+	conf *csvConfig
+}
+
+// NewCsvCodec 创建CSV编解码器.
+// 编码支持[][]string和结构体切片, 结构体切片会先写入由csv标签(默认为字段名)组成的表头;
+// 解码支持*[][]string和结构体切片指针, 按表头与csv标签的对应关系填充字段.
+// Creating a CSV codec.
+// Encoding accepts [][]string and slices of structs, the latter are preceded by a header row made of csv tags (field names by default);
+// Decoding accepts *[][]string and pointers to slices of structs, fields are filled by mapping the header to csv tags.
+func NewCsvCodec(options ...CsvOption) Codec {
+	return &csvCodec{conf: newCsvConfig(options...)}
+}
+
+func (c *csvCodec) Encode(v any) (io.Reader, error) {
+	if v == nil {
+		return nil, nil
+	}
+	w := bytebufferpool.Get()
+	err := c.encode(w, v)
+	r := &internal.CloserWrapper{B: w, R: bytes.NewReader(w.B)}
+	return r, errors.WithStack(err)
+}
+
+func (c *csvCodec) encode(w io.Writer, v any) error {
+	var writer = csv.NewWriter(w)
+	writer.Comma = c.conf.comma
+	if records, ok := v.([][]string); ok {
+		return writer.WriteAll(records)
+	}
+
+	var values = reflect.Indirect(reflect.ValueOf(v))
+	if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+		return errUnsupportedData
+	}
+	elemType, isPtr := csvElemType(values.Type().Elem())
+	if elemType == nil {
+		return errUnsupportedData
+	}
+
+	var fields = csvFields(elemType)
+	var record = make([]string, len(fields))
+	for i, f := range fields {
+		record[i] = f.name
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+	for i := 0; i < values.Len(); i++ {
+		var item = values.Index(i)
+		if isPtr {
+			if item.IsNil() {
+				continue
+			}
+			item = item.Elem()
+		}
+		for j, f := range fields {
+			s, err := formatCsvValue(item.FieldByIndex(f.index))
+			if err != nil {
+				return errors.Wrapf(err, "csv: field %s", f.name)
+			}
+			record[j] = s
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (c *csvCodec) ContentType() string {
+	if c.conf.comma == '\t' {
+		return MimeTsv
+	}
+	return MimeCsv
+}
+
+func (c *csvCodec) Decode(r io.Reader, v any) error {
+	var reader = c.conf.newReader(r)
+	if records, ok := v.(*[][]string); ok {
+		result, err := reader.ReadAll()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		*records = result
+		return nil
+	}
+
+	var values = reflect.ValueOf(v)
+	if values.Kind() != reflect.Pointer || values.IsNil() || values.Elem().Kind() != reflect.Slice {
+		return errors.Wrap(errUnsupportedData, "v must be *[][]string or a pointer to a slice of structs")
+	}
+	var slice = values.Elem()
+	elemType, isPtr := csvElemType(slice.Type().Elem())
+	if elemType == nil {
+		return errors.Wrap(errUnsupportedData, "v must be *[][]string or a pointer to a slice of structs")
+	}
+
+	var result = reflect.MakeSlice(slice.Type(), 0, 0)
+	header, err := reader.Read()
+	if err == io.EOF {
+		slice.Set(result)
+		return nil
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var binder = newCsvBinder(elemType, header)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		var item = reflect.New(elemType)
+		if err := binder.bind(reader, item.Elem(), record); err != nil {
+			return err
+		}
+		if isPtr {
+			result = reflect.Append(result, item)
+		} else {
+			result = reflect.Append(result, item.Elem())
+		}
+	}
+	slice.Set(result)
+	return nil
+}
+
+// CsvIterator 逐行解码CSV数据, 适用于无法一次性读入内存的大文件
+// Decodes CSV data row by row, suitable for files too large to decode at once
+type CsvIterator[T any] struct {
+	reader   *csv.Reader
+	closer   io.Closer
+	elemType reflect.Type
+	isPtr    bool
+	raw      bool
+	binder   *csvBinder
+	header   []string
+	item     T
+	err      error
+}
+
+// NewCsvIterator 创建CSV迭代器. T为结构体或结构体指针时首行作为表头; T为[]string时返回包括首行在内的所有行.
+// r实现io.Closer时, 由Close关闭.
+// Creating a CSV iterator. When T is a struct or a pointer to a struct the first row is the header;
+// when T is []string every row including the first one is returned.
+// If r implements io.Closer, it is closed by Close.
+func NewCsvIterator[T any](r io.Reader, options ...CsvOption) *CsvIterator[T] {
+	var c = &CsvIterator[T]{reader: newCsvConfig(options...).newReader(r)}
+	c.closer, _ = r.(io.Closer)
+	var typ = reflect.TypeOf((*T)(nil)).Elem()
+	if typ == reflect.TypeOf([]string(nil)) {
+		c.raw = true
+	} else if c.elemType, c.isPtr = csvElemType(typ); c.elemType == nil {
+		c.err = errors.Wrap(errUnsupportedData, "T must be []string, a struct or a pointer to a struct")
+	}
+	return c
+}
+
+// Next 移动到下一行, 没有更多数据或者出错时返回false
+// Moves to the next row, returns false when there is no more data or an error occurred
+func (c *CsvIterator[T]) Next() bool {
+	if c.err != nil {
+		return false
+	}
+	if !c.raw && c.binder == nil {
+		header, err := c.reader.Read()
+		if err != nil {
+			c.err = err
+			return false
+		}
+		c.header = header
+		c.binder = newCsvBinder(c.elemType, header)
+	}
+
+	record, err := c.reader.Read()
+	if err != nil {
+		c.err = err
+		return false
+	}
+	if c.raw {
+		c.item = any(record).(T)
+		return true
+	}
+	var item = reflect.New(c.elemType)
+	if c.err = c.binder.bind(c.reader, item.Elem(), record); c.err != nil {
+		return false
+	}
+	if c.isPtr {
+		c.item = item.Interface().(T)
+	} else {
+		c.item = item.Elem().Interface().(T)
+	}
+	return true
+}
+
+// Item 返回当前行
+// Returns the current row
+func (c *CsvIterator[T]) Item() T {
+	return c.item
+}
+
+// Header 返回表头, T为[]string时为空
+// Returns the header row, it is empty when T is []string
+func (c *CsvIterator[T]) Header() []string {
+	return c.header
+}
+
+// Err 返回遍历过程中的错误, 正常结束时为nil
+// Returns the error that occurred during iteration, it is nil at a normal end
+func (c *CsvIterator[T]) Err() error {
+	if c.err == io.EOF {
+		return nil
+	}
+	return errors.WithStack(c.err)
+}
+
+// Close 关闭底层的数据源
+// Closes the underlying data source
+func (c *CsvIterator[T]) Close() error {
+	if c.closer == nil {
+		return nil
+	}
+	return c.closer.Close()
+}
+
+type csvField struct {
+	name  string
+	index []int
+}
+
+// csvElemType 返回切片元素的结构体类型以及元素是否为指针, 不是结构体时返回nil
+func csvElemType(typ reflect.Type) (reflect.Type, bool) {
+	var isPtr = typ.Kind() == reflect.Pointer
+	if isPtr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, false
+	}
+	return typ, isPtr
+}
+
+// csvFields 返回结构体的可导出字段, 匿名结构体字段会被展开, csv标签为"-"的字段被忽略
+func csvFields(typ reflect.Type) []csvField {
+	if v, ok := csvFieldsCache.Load(typ); ok {
+		return v.([]csvField)
+	}
+	var fields []csvField
+	for i := 0; i < typ.NumField(); i++ {
+		var field = typ.Field(i)
+		var tag = field.Tag.Get(csvTagName)
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && tag == "" {
+			for _, item := range csvFields(field.Type) {
+				item.index = append([]int{i}, item.index...)
+				fields = append(fields, item)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		var name, _, _ = strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, csvField{name: name, index: field.Index})
+	}
+	csvFieldsCache.Store(typ, fields)
+	return fields
+}
+
+type csvBinder struct {
+	header  []string
+	columns [][]int
+}
+
+// newCsvBinder 按表头匹配字段, 优先完全匹配, 其次忽略大小写匹配
+func newCsvBinder(typ reflect.Type, header []string) *csvBinder {
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	var fields = csvFields(typ)
+	var b = &csvBinder{header: header, columns: make([][]int, len(header))}
+	for i, name := range header {
+		for _, f := range fields {
+			if f.name == name {
+				b.columns[i] = f.index
+				break
+			}
+		}
+		if b.columns[i] != nil {
+			continue
+		}
+		for _, f := range fields {
+			if strings.EqualFold(f.name, name) {
+				b.columns[i] = f.index
+				break
+			}
+		}
+	}
+	return b
+}
+
+func (c *csvBinder) bind(reader *csv.Reader, v reflect.Value, record []string) error {
+	for i, s := range record {
+		if i >= len(c.columns) || c.columns[i] == nil {
+			continue
+		}
+		if err := parseCsvValue(v.FieldByIndex(c.columns[i]), s); err != nil {
+			line, _ := reader.FieldPos(i)
+			return errors.Wrapf(err, "csv: line %d, column %s", line, c.header[i])
+		}
+	}
+	return nil
+}
+
+func formatCsvValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		p, err := m.MarshalText()
+		return string(p), err
+	}
+	if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			p, err := m.MarshalText()
+			return string(p), err
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	default:
+		return "", errUnsupportedData
+	}
+}
+
+// parseCsvValue 将s解析到v中, 空字符串保留零值
+func parseCsvValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if s == "" {
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	if s == "" && v.Kind() != reflect.String {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return errUnsupportedData
+	}
+	return nil
+}
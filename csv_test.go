@@ -0,0 +1,239 @@
+package hasaki
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type csvBase struct {
+	ID int `csv:"id"`
+}
+
+type csvReport struct {
+	csvBase
+	Name    string    `csv:"name"`
+	Score   float64   `csv:"score"`
+	Active  bool      `csv:"active,omitempty"`
+	Note    *string   `csv:"note"`
+	At      time.Time `csv:"at"`
+	Ignored string    `csv:"-"`
+	Count   uint8
+	Expire  *time.Time `csv:"expire"`
+}
+
+func TestCsvCodec_ContentType(t *testing.T) {
+	assert.Equal(t, CsvCodec.ContentType(), MimeCsv)
+	assert.Equal(t, TsvCodec.ContentType(), MimeTsv)
+}
+
+func TestCsvCodec_Encode(t *testing.T) {
+	var at = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("structs", func(t *testing.T) {
+		var note = "a,b"
+		var rows = []csvReport{
+			{csvBase: csvBase{ID: 1}, Name: "caster", Score: 1.5, Active: true, Note: &note, At: at, Ignored: "x", Count: 2},
+			{csvBase: csvBase{ID: 2}, Name: "lancer", At: at},
+		}
+		r, err := CsvCodec.Encode(rows)
+		assert.NoError(t, err)
+		p, _ := io.ReadAll(r)
+		assert.Equal(t, string(p), "id,name,score,active,note,at,Count,expire\n"+
+			"1,caster,1.5,true,\"a,b\",2024-01-02T03:04:05Z,2,\n"+
+			"2,lancer,0,false,,2024-01-02T03:04:05Z,0,\n")
+	})
+
+	t.Run("pointers", func(t *testing.T) {
+		r, err := TsvCodec.Encode(&[]*csvBase{{ID: 1}, nil, {ID: 3}})
+		assert.NoError(t, err)
+		p, _ := io.ReadAll(r)
+		assert.Equal(t, string(p), "id\n1\n3\n")
+	})
+
+	t.Run("records", func(t *testing.T) {
+		r, err := TsvCodec.Encode([][]string{{"a", "b"}, {"1", "2"}})
+		assert.NoError(t, err)
+		p, _ := io.ReadAll(r)
+		assert.Equal(t, string(p), "a\tb\n1\t2\n")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		r, err := CsvCodec.Encode(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, r)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := CsvCodec.Encode(csvBase{})
+		assert.True(t, errors.Is(err, errUnsupportedData))
+
+		_, err = CsvCodec.Encode([]int{1})
+		assert.True(t, errors.Is(err, errUnsupportedData))
+
+		_, err = CsvCodec.Encode([]struct{ C chan int }{{}})
+		assert.True(t, errors.Is(err, errUnsupportedData))
+	})
+}
+
+func TestCsvCodec_Decode(t *testing.T) {
+	t.Run("structs", func(t *testing.T) {
+		var data = "\ufeffID,Name,score,extra,note,at,expire\n" +
+			"1,caster,1.5,x,hello,2024-01-02T03:04:05Z,\n" +
+			"2,lancer,,y,,2024-01-02T03:04:05Z,2024-01-02T03:04:05Z\n"
+		var rows []csvReport
+		assert.NoError(t, CsvCodec.Decode(strings.NewReader(data), &rows))
+		assert.Equal(t, len(rows), 2)
+		assert.Equal(t, rows[0].ID, 1)
+		assert.Equal(t, rows[0].Name, "caster")
+		assert.Equal(t, rows[0].Score, 1.5)
+		assert.Equal(t, *rows[0].Note, "hello")
+		assert.Nil(t, rows[0].Expire)
+		assert.Equal(t, rows[1].Score, 0.0)
+		assert.Nil(t, rows[1].Note)
+		assert.True(t, rows[1].At.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+		assert.NotNil(t, rows[1].Expire)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		var codec = NewCsvCodec(WithCsvComma(';'), WithCsvComment('#'), WithCsvLazyQuotes())
+		var data = "id;name\n# comment\n1;ca\"ster\n"
+		var rows []*csvReport
+		assert.NoError(t, codec.Decode(strings.NewReader(data), &rows))
+		assert.Equal(t, len(rows), 1)
+		assert.Equal(t, rows[0].Name, "ca\"ster")
+
+		assert.Error(t, CsvCodec.Decode(strings.NewReader("id,name\n1,ca\"ster\n"), &rows))
+	})
+
+	t.Run("records", func(t *testing.T) {
+		var records [][]string
+		assert.NoError(t, TsvCodec.Decode(strings.NewReader("a\tb\n1\t2\n"), &records))
+		assert.Equal(t, records, [][]string{{"a", "b"}, {"1", "2"}})
+		assert.Error(t, TsvCodec.Decode(strings.NewReader("a\tb\n1\n"), &records))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var rows = []csvReport{{Name: "caster"}}
+		assert.NoError(t, CsvCodec.Decode(strings.NewReader(""), &rows))
+		assert.Equal(t, len(rows), 0)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		var rows []csvReport
+		var err = CsvCodec.Decode(strings.NewReader("id,name\n1,caster\nx,lancer\n"), &rows)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "line 3, column id")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		var rows []csvReport
+		assert.True(t, errors.Is(CsvCodec.Decode(strings.NewReader(""), rows), errUnsupportedData))
+		var ints []int
+		assert.True(t, errors.Is(CsvCodec.Decode(strings.NewReader(""), &ints), errUnsupportedData))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		var rows = []csvBase{{ID: 1}, {ID: 2}}
+		r, _ := CsvCodec.Encode(rows)
+		var result []csvBase
+		assert.NoError(t, CsvCodec.Decode(r, &result))
+		assert.Equal(t, result, rows)
+	})
+}
+
+func TestCsvIterator(t *testing.T) {
+	var data = "id,name\n1,caster\n2,lancer\n"
+
+	t.Run("structs", func(t *testing.T) {
+		var iter = NewCsvIterator[csvReport](io.NopCloser(strings.NewReader(data)))
+		var names []string
+		for iter.Next() {
+			names = append(names, iter.Item().Name)
+		}
+		assert.NoError(t, iter.Err())
+		assert.NoError(t, iter.Close())
+		assert.Equal(t, names, []string{"caster", "lancer"})
+		assert.Equal(t, iter.Header(), []string{"id", "name"})
+	})
+
+	t.Run("pointers", func(t *testing.T) {
+		var iter = NewCsvIterator[*csvBase](strings.NewReader(data))
+		var ids []int
+		for iter.Next() {
+			ids = append(ids, iter.Item().ID)
+		}
+		assert.NoError(t, iter.Err())
+		assert.NoError(t, iter.Close())
+		assert.Equal(t, ids, []int{1, 2})
+	})
+
+	t.Run("records", func(t *testing.T) {
+		var iter = NewCsvIterator[[]string](strings.NewReader("a\tb\n1\t2\n"), WithCsvComma('\t'))
+		var records [][]string
+		for iter.Next() {
+			records = append(records, iter.Item())
+		}
+		assert.NoError(t, iter.Err())
+		assert.Equal(t, records, [][]string{{"a", "b"}, {"1", "2"}})
+		assert.Nil(t, iter.Header())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var iter = NewCsvIterator[csvBase](strings.NewReader("id\n1\nx\n"))
+		assert.True(t, iter.Next())
+		assert.False(t, iter.Next())
+		assert.False(t, iter.Next())
+		assert.Error(t, iter.Err())
+
+		var iter2 = NewCsvIterator[int](strings.NewReader(data))
+		assert.False(t, iter2.Next())
+		assert.True(t, errors.Is(iter2.Err(), errUnsupportedData))
+
+		var iter3 = NewCsvIterator[csvBase](strings.NewReader(""))
+		assert.False(t, iter3.Next())
+		assert.NoError(t, iter3.Err())
+	})
+}
+
+func TestCsvCodec_Client(t *testing.T) {
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, request.Header.Get("Content-Type"), MimeCsv)
+		var rows []csvBase
+		assert.NoError(t, CsvCodec.Decode(request.Body, &rows))
+		for i := range rows {
+			rows[i].ID *= 10
+		}
+		writer.Header().Set("Content-Type", MimeCsv)
+		r, _ := CsvCodec.Encode(rows)
+		io.Copy(writer, r)
+	})
+	go srv.ListenAndServe()
+	defer srv.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	t.Run("bind", func(t *testing.T) {
+		var rows []csvBase
+		var err = Post("http://"+addr).SetEncoder(CsvCodec).Send([]csvBase{{ID: 1}, {ID: 2}}).Bind(&rows, CsvCodec)
+		assert.NoError(t, err)
+		assert.Equal(t, rows, []csvBase{{ID: 10}, {ID: 20}})
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		var resp = Post("http://" + addr).SetEncoder(NewStreamEncoder(MimeCsv)).Send("id\n3\n")
+		assert.NoError(t, resp.Err())
+		var iter = NewCsvIterator[csvBase](resp.Body)
+		defer iter.Close()
+		assert.True(t, iter.Next())
+		assert.Equal(t, iter.Item().ID, 30)
+		assert.False(t, iter.Next())
+		assert.NoError(t, iter.Err())
+	})
+}
@@ -0,0 +1,586 @@
+package hasaki
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"io"
+	"mime/multipart"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var errInvalidCURL = errors.New("invalid curl command")
+
+type (
+	curlConfig struct {
+		fileDir string
+	}
+
+	// CURLOption FromCURL配置
+	// FromCURL option
+	CURLOption func(c *curlConfig)
+)
+
+// WithCURLFileAccess 允许读取dir目录下的文件, 用于 -d @file, --data-binary @file, --data-urlencode name@file, -F name=@file 和 -F name=<file.
+// 相对路径基于dir解析, 超出dir的路径会返回错误.
+// Allowing the files under dir to be read for -d @file, --data-binary @file, --data-urlencode name@file, -F name=@file and -F name=<file.
+// Relative paths are resolved against dir, paths outside dir are rejected.
+func WithCURLFileAccess(dir string) CURLOption {
+	return func(c *curlConfig) {
+		c.fileDir = dir
+	}
+}
+
+// FromCURL 解析CURL命令, 生成一个请求. 命令中携带的body会在调用Send(nil)时发送.
+// 默认不读取本地文件, 引用文件的参数会返回错误, 需要通过WithCURLFileAccess授权; 任何情况下都不读取标准输入(@-).
+// Parse a cURL command into a request. The body carried by the command is sent when Send(nil) is called.
+// Local files are not read by default and options referencing them return an error unless WithCURLFileAccess is given; stdin (@-) is never read.
+func FromCURL(cmd string, options ...CURLOption) (*Request, error) {
+	return defaultClient.FromCURL(cmd, options...)
+}
+
+// FromCURL 使用当前客户端解析CURL命令, 生成一个请求
+// Parse a cURL command into a request that uses the current client
+func (c *Client) FromCURL(cmd string, options ...CURLOption) (*Request, error) {
+	args, err := splitCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.Wrap(errInvalidCURL, "command must start with curl")
+	}
+
+	var conf = new(curlConfig)
+	for _, f := range options {
+		f(conf)
+	}
+	var p = &curlParser{header: http.Header{}, conf: conf}
+	if err := p.parse(args[1:]); err != nil {
+		return nil, err
+	}
+	return p.build(c)
+}
+
+type curlParser struct {
+	conf       *curlConfig
+	method     string
+	url        string
+	header     http.Header
+	data       []string
+	form       *multipart.Writer
+	formBuffer *bytes.Buffer
+	get        bool
+	head       bool
+	insecure   bool
+}
+
+// curl选项是否需要参数; 不在表中的选项视为不支持
+var curlOptions = map[string]bool{
+	"-X":                true,
+	"--request":         true,
+	"-H":                true,
+	"--header":          true,
+	"-d":                true,
+	"--data":            true,
+	"--data-ascii":      true,
+	"--data-raw":        true,
+	"--data-binary":     true,
+	"--data-urlencode":  true,
+	"-F":                true,
+	"--form":            true,
+	"--form-string":     true,
+	"-u":                true,
+	"--user":            true,
+	"-b":                true,
+	"--cookie":          true,
+	"-A":                true,
+	"--user-agent":      true,
+	"-e":                true,
+	"--referer":         true,
+	"--url":             true,
+	"-o":                true,
+	"--output":          true,
+	"-m":                true,
+	"--max-time":        true,
+	"--connect-timeout": true,
+	"-w":                true,
+	"--write-out":       true,
+	"--compressed":      false,
+	"-k":                false,
+	"--insecure":        false,
+	"-G":                false,
+	"--get":             false,
+	"-I":                false,
+	"--head":            false,
+	"-L":                false,
+	"--location":        false,
+	"-s":                false,
+	"--silent":          false,
+	"-S":                false,
+	"--show-error":      false,
+	"-v":                false,
+	"--verbose":         false,
+	"-i":                false,
+	"--include":         false,
+	"-f":                false,
+	"--fail":            false,
+	"-g":                false,
+	"--globoff":         false,
+	"--http1.1":         false,
+	"--http2":           false,
+}
+
+func (c *curlParser) parse(args []string) error {
+	for i := 0; i < len(args); i++ {
+		var flag, value = args[i], ""
+		if !strings.HasPrefix(flag, "-") || flag == "-" {
+			if c.url != "" {
+				return errors.Wrapf(errInvalidCURL, "unexpected argument %s", flag)
+			}
+			c.url = flag
+			continue
+		}
+
+		// 短选项的参数可以紧跟在选项之后, 例如 -XPOST; 不带参数的短选项可以合并, 例如 -sSL
+		if !strings.HasPrefix(flag, "--") && len(flag) > 2 {
+			if withValue, ok := curlOptions[flag[:2]]; ok && withValue {
+				flag, value = flag[:2], flag[2:]
+			} else if flags := splitShortFlags(flag); flags != nil {
+				for _, item := range flags {
+					if err := c.apply(item, ""); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+		}
+
+		withValue, ok := curlOptions[flag]
+		if !ok {
+			return errors.Wrapf(errInvalidCURL, "unsupported option %s", flag)
+		}
+		if withValue && value == "" {
+			if i+1 >= len(args) {
+				return errors.Wrapf(errInvalidCURL, "option %s requires a value", flag)
+			}
+			i++
+			value = args[i]
+		}
+
+		if err := c.apply(flag, value); err != nil {
+			return err
+		}
+	}
+
+	if c.url == "" {
+		return errors.Wrap(errInvalidCURL, "missing url")
+	}
+	return nil
+}
+
+// splitShortFlags 拆分合并的短选项, 只有每个字母都是不带参数的选项时才拆分
+func splitShortFlags(flag string) []string {
+	var flags = make([]string, 0, len(flag)-1)
+	for _, letter := range flag[1:] {
+		var item = "-" + string(letter)
+		if withValue, ok := curlOptions[item]; !ok || withValue {
+			return nil
+		}
+		flags = append(flags, item)
+	}
+	return flags
+}
+
+func (c *curlParser) apply(flag, value string) error {
+	switch flag {
+	case "-X", "--request":
+		c.method = strings.ToUpper(value)
+	case "-H", "--header":
+		k, v, _ := strings.Cut(value, ":")
+		if v = strings.TrimSpace(v); v != "" {
+			c.header.Add(strings.TrimSpace(k), v)
+		}
+	case "-d", "--data", "--data-ascii":
+		if strings.HasPrefix(value, "@") {
+			p, err := c.readFile(value[1:])
+			if err != nil {
+				return err
+			}
+			value = strings.NewReplacer("\r", "", "\n", "").Replace(string(p))
+		}
+		c.data = append(c.data, value)
+	case "--data-binary":
+		if strings.HasPrefix(value, "@") {
+			p, err := c.readFile(value[1:])
+			if err != nil {
+				return err
+			}
+			value = string(p)
+		}
+		c.data = append(c.data, value)
+	case "--data-raw":
+		c.data = append(c.data, value)
+	case "--data-urlencode":
+		v, err := c.urlencodeData(value)
+		if err != nil {
+			return err
+		}
+		c.data = append(c.data, v)
+	case "-F", "--form":
+		return c.addFormField(value, false)
+	case "--form-string":
+		return c.addFormField(value, true)
+	case "-u", "--user":
+		c.header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(value)))
+	case "-b", "--cookie":
+		if !strings.Contains(value, "=") {
+			return errors.Wrapf(errInvalidCURL, "cookie file %s is not supported", value)
+		}
+		c.header.Add("Cookie", value)
+	case "-A", "--user-agent":
+		c.header.Set("User-Agent", value)
+	case "-e", "--referer":
+		c.header.Set("Referer", value)
+	case "--url":
+		c.url = value
+	case "-k", "--insecure":
+		c.insecure = true
+	case "-G", "--get":
+		c.get = true
+	case "-I", "--head":
+		c.head = true
+	case "--compressed":
+		// Go的Transport默认协商gzip并自动解压, build时会删除复制来的Accept-Encoding
+	}
+	return nil
+}
+
+// readFile 读取WithCURLFileAccess授权目录下的文件
+func (c *curlParser) readFile(name string) ([]byte, error) {
+	if name == "-" {
+		return nil, errors.Wrap(errInvalidCURL, "reading from stdin is not supported")
+	}
+	if c.conf.fileDir == "" {
+		return nil, errors.Wrapf(errInvalidCURL, "reading file %s requires WithCURLFileAccess", name)
+	}
+	dir, err := filepath.Abs(c.conf.fileDir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var path = name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, errors.Wrapf(errInvalidCURL, "file %s is outside %s", name, c.conf.fileDir)
+	}
+	p, err := os.ReadFile(path)
+	return p, errors.WithStack(err)
+}
+
+// urlencodeData 支持 content, =content, name=content, @filename, name@filename 五种格式
+func (c *curlParser) urlencodeData(value string) (string, error) {
+	if i := strings.IndexAny(value, "=@"); i >= 0 {
+		var name, content = value[:i], value[i+1:]
+		if value[i] == '@' {
+			p, err := c.readFile(content)
+			if err != nil {
+				return "", err
+			}
+			content = string(p)
+		}
+		if name == "" {
+			return neturl.QueryEscape(content), nil
+		}
+		return name + "=" + neturl.QueryEscape(content), nil
+	}
+	return neturl.QueryEscape(value), nil
+}
+
+func (c *curlParser) addFormField(value string, literal bool) error {
+	if c.form == nil {
+		c.formBuffer = bytes.NewBuffer(nil)
+		c.form = multipart.NewWriter(c.formBuffer)
+	}
+
+	name, content, ok := strings.Cut(value, "=")
+	if !ok {
+		return errors.Wrapf(errInvalidCURL, "illegal form field %s", value)
+	}
+	if literal || (!strings.HasPrefix(content, "@") && !strings.HasPrefix(content, "<")) {
+		return errors.WithStack(c.form.WriteField(name, content))
+	}
+
+	// 文件参数可以带有 ;type= 和 ;filename= 属性
+	var segments = strings.Split(content[1:], ";")
+	var path, contentType, filename = segments[0], "", filepath.Base(segments[0])
+	for _, item := range segments[1:] {
+		k, v, _ := strings.Cut(item, "=")
+		switch strings.TrimSpace(k) {
+		case "type":
+			contentType = v
+		case "filename":
+			filename = v
+		}
+	}
+
+	p, err := c.readFile(path)
+	if err != nil {
+		return err
+	}
+	if content[0] == '<' {
+		return errors.WithStack(c.form.WriteField(name, string(p)))
+	}
+
+	var h = make(map[string][]string)
+	h["Content-Disposition"] = []string{`form-data; name="` + escapeQuotes(name) + `"; filename="` + escapeQuotes(filename) + `"`}
+	if contentType == "" {
+		contentType = MimeStream
+	}
+	h["Content-Type"] = []string{contentType}
+	part, err := c.form.CreatePart(h)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = part.Write(p)
+	return errors.WithStack(err)
+}
+
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
+
+func (c *curlParser) build(client *Client) (*Request, error) {
+	var rawURL = c.url
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	var encoder = &rawEncoder{contentType: c.header.Get("Content-Type")}
+	var method = http.MethodGet
+	switch {
+	case c.form != nil:
+		if err := c.form.Close(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		encoder.contentType = c.form.FormDataContentType()
+		encoder.data = c.formBuffer.Bytes()
+		method = http.MethodPost
+	case len(c.data) > 0 && c.get:
+		var sep = "?"
+		if strings.Contains(rawURL, "?") {
+			sep = "&"
+		}
+		rawURL += sep + strings.Join(c.data, "&")
+	case len(c.data) > 0:
+		if encoder.contentType == "" {
+			encoder.contentType = MimeForm
+		}
+		encoder.data = []byte(strings.Join(c.data, "&"))
+		method = http.MethodPost
+	}
+	if c.head {
+		method = http.MethodHead
+	}
+	if c.method != "" {
+		method = c.method
+	}
+
+	var r = client.Request(method, rawURL)
+	r.SetHeaders(c.header)
+	r.SetEncoder(encoder)
+	if encoder.contentType == "" {
+		r.headers.Del("Content-Type")
+	}
+	// 浏览器复制的Accept-Encoding会关闭Go的透明gzip解压, 交给Transport或WithDecompression协商
+	r.headers.Del("Accept-Encoding")
+
+	if c.insecure {
+		cli, err := insecureClient(client.config)
+		if err != nil {
+			return nil, err
+		}
+		r.client = cli
+	}
+	return r, nil
+}
+
+// insecureClient 复制HTTP客户端, 在中间件包装前的Transport上跳过证书校验, 再重新应用Transport中间件
+func insecureClient(conf *config) (*http.Client, error) {
+	var transport, ok = conf.BaseTransport.(*http.Transport)
+	if conf.BaseTransport == nil {
+		transport, ok = http.DefaultTransport.(*http.Transport)
+	}
+	if !ok {
+		return nil, errors.Wrap(errInvalidCURL, "--insecure requires *http.Transport")
+	}
+
+	transport = transport.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.InsecureSkipVerify = true
+	var cli = *conf.HTTPClient
+	cli.Transport = transport
+	for i := len(conf.Transports) - 1; i >= 0; i-- {
+		cli.Transport = conf.Transports[i](cli.Transport)
+	}
+	return &cli, nil
+}
+
+// rawEncoder 在body为nil时发送预置的数据, 否则与streamEncoder相同
+type rawEncoder struct {
+	contentType string
+	data        []byte
+}
+
+func (c *rawEncoder) Encode(v any) (io.Reader, error) {
+	if v == nil {
+		if len(c.data) == 0 {
+			return nil, nil
+		}
+		return bytes.NewReader(c.data), nil
+	}
+	return NewStreamEncoder(c.contentType).Encode(v)
+}
+
+func (c *rawEncoder) ContentType() string {
+	return c.contentType
+}
+
+// splitCommand 按照shell规则拆分命令, 支持单引号, 双引号, $'...'和续行符
+func splitCommand(cmd string) ([]string, error) {
+	var args []string
+	var word = strings.Builder{}
+	var inWord = false
+	var runes = []rune(cmd)
+
+	for i := 0; i < len(runes); i++ {
+		var ch = runes[i]
+		switch {
+		case ch == '\\' && i+1 < len(runes):
+			i++
+			if runes[i] == '\n' || (runes[i] == '\r' && i+1 < len(runes) && runes[i+1] == '\n') {
+				if runes[i] == '\r' {
+					i++
+				}
+				continue
+			}
+			word.WriteRune(runes[i])
+			inWord = true
+
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		case ch == '\'':
+			var end = indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, errors.Wrap(errInvalidCURL, "unterminated single quote")
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			i, inWord = end, true
+
+		case ch == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			next, err := readANSIQuoted(runes, i+2, &word)
+			if err != nil {
+				return nil, err
+			}
+			i, inWord = next, true
+
+		case ch == '"':
+			var closed = false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '"' {
+					closed = true
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\\\"$`\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, errors.Wrap(errInvalidCURL, "unterminated double quote")
+			}
+			inWord = true
+
+		default:
+			word.WriteRune(ch)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+func indexRune(runes []rune, offset int, target rune) int {
+	for i := offset; i < len(runes); i++ {
+		if runes[i] == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// readANSIQuoted 读取 $'...' 中的内容, 返回结束引号的位置
+func readANSIQuoted(runes []rune, offset int, word *strings.Builder) (int, error) {
+	for i := offset; i < len(runes); i++ {
+		if runes[i] == '\'' {
+			return i, nil
+		}
+		if runes[i] != '\\' || i+1 >= len(runes) {
+			word.WriteRune(runes[i])
+			continue
+		}
+
+		i++
+		switch runes[i] {
+		case 'n':
+			word.WriteByte('\n')
+		case 't':
+			word.WriteByte('\t')
+		case 'r':
+			word.WriteByte('\r')
+		case 'x', 'u':
+			var size = 2
+			if runes[i] == 'u' {
+				size = 4
+			}
+			var end = i + 1
+			for end < len(runes) && end < i+1+size && strings.ContainsRune("0123456789abcdefABCDEF", runes[end]) {
+				end++
+			}
+			num, err := strconv.ParseUint(string(runes[i+1:end]), 16, 32)
+			if err != nil {
+				return 0, errors.Wrap(errInvalidCURL, "illegal escape sequence")
+			}
+			if runes[i] == 'x' {
+				word.WriteByte(byte(num))
+			} else {
+				word.WriteRune(rune(num))
+			}
+			i = end - 1
+		default:
+			word.WriteRune(runes[i])
+		}
+	}
+	return 0, errors.Wrap(errInvalidCURL, "unterminated ANSI-C quote")
+}
@@ -0,0 +1,231 @@
+package hasaki
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCommand(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		args, err := splitCommand("curl 'http://127.0.0.1' \\\n  -H \"X-Name: \\\"caster\\\"\" --data-raw $'{\"a\":\"it\\'s\\n\\u4e2d\"}' a\\ b")
+		assert.NoError(t, err)
+		assert.Equal(t, args, []string{"curl", "http://127.0.0.1", "-H", `X-Name: "caster"`, "--data-raw", "{\"a\":\"it's\n中\"}", "a b"})
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err1 := splitCommand("curl 'abc")
+		assert.True(t, errors.Is(err1, errInvalidCURL))
+		_, err2 := splitCommand(`curl "abc`)
+		assert.True(t, errors.Is(err2, errInvalidCURL))
+		_, err3 := splitCommand(`curl $'abc`)
+		assert.True(t, errors.Is(err3, errInvalidCURL))
+	})
+}
+
+func TestFromCURL(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		req, err := FromCURL(`curl 'https://api.example.com/users' -H 'Content-Type: application/json' -XPUT --data-raw '{"name":"caster"}' --compressed`)
+		assert.NoError(t, err)
+		assert.Equal(t, req.method, http.MethodPut)
+		assert.Equal(t, req.url, "https://api.example.com/users")
+		assert.Equal(t, req.headers.Get("Content-Type"), "application/json")
+
+		cmd, _ := req.ToCURL(nil)
+		assert.Equal(t, cmd, "curl -X PUT 'https://api.example.com/users' \\\n"+
+			"    --header 'Content-Type: application/json' \\\n"+
+			`    --data-raw '{"name":"caster"}'`)
+	})
+
+	t.Run("form", func(t *testing.T) {
+		req, err := FromCURL(`curl api.example.com -d a=1 --data-urlencode 'b=x y' -u user:pass -b 'sid=1' -A hasaki -e http://example.com`)
+		assert.NoError(t, err)
+		assert.Equal(t, req.method, http.MethodPost)
+		assert.Equal(t, req.url, "http://api.example.com")
+		assert.Equal(t, req.headers.Get("Content-Type"), MimeForm)
+		assert.Equal(t, req.headers.Get("Authorization"), "Basic dXNlcjpwYXNz")
+		assert.Equal(t, req.headers.Get("Cookie"), "sid=1")
+		assert.Equal(t, req.headers.Get("User-Agent"), "hasaki")
+		assert.Equal(t, req.headers.Get("Referer"), "http://example.com")
+
+		reader, _ := req.encoder.Encode(nil)
+		p, _ := io.ReadAll(reader)
+		assert.Equal(t, string(p), "a=1&b=x+y")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		req, err := FromCURL(`curl -G 'http://127.0.0.1/search?page=1' -d q=hasaki`)
+		assert.NoError(t, err)
+		assert.Equal(t, req.method, http.MethodGet)
+		assert.Equal(t, req.url, "http://127.0.0.1/search?page=1&q=hasaki")
+		assert.Equal(t, req.headers.Get("Content-Type"), "")
+
+		req, err = FromCURL(`curl -I http://127.0.0.1`)
+		assert.NoError(t, err)
+		assert.Equal(t, req.method, http.MethodHead)
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		var dir = t.TempDir()
+		var filename = filepath.Join(dir, "a.txt")
+		assert.NoError(t, os.WriteFile(filename, []byte("hello"), 0644))
+		req, err := FromCURL(`curl http://127.0.0.1 -F name=caster -F 'file=@`+filename+`;type=text/plain' --form-string 'x=@y'`, WithCURLFileAccess(dir))
+		assert.NoError(t, err)
+		assert.Equal(t, req.method, http.MethodPost)
+
+		cmd, _ := req.ToCURL(nil)
+		assert.Equal(t, cmd, "curl -X POST 'http://127.0.0.1' \\\n"+
+			"    --form-string 'name=caster' \\\n"+
+			"    --form 'file=@a.txt;type=text/plain' \\\n"+
+			"    --form-string 'x=@y'")
+	})
+
+	t.Run("file access", func(t *testing.T) {
+		var dir = t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello\nworld"), 0644))
+
+		req, err := FromCURL(`curl http://127.0.0.1 -d @a.txt --data-urlencode 'b@a.txt'`, WithCURLFileAccess(dir))
+		assert.NoError(t, err)
+		reader, _ := req.encoder.Encode(nil)
+		p, _ := io.ReadAll(reader)
+		assert.Equal(t, string(p), "helloworld&b=hello%0Aworld")
+
+		for _, cmd := range []string{
+			`curl http://127.0.0.1 -d @a.txt`,
+			`curl http://127.0.0.1 --data-binary @a.txt`,
+			`curl http://127.0.0.1 --data-urlencode b@a.txt`,
+			`curl http://127.0.0.1 -F file=@a.txt`,
+			`curl http://127.0.0.1 -F 'file=<a.txt'`,
+		} {
+			_, err := FromCURL(cmd)
+			assert.True(t, errors.Is(err, errInvalidCURL), cmd)
+		}
+
+		for _, cmd := range []string{
+			`curl http://127.0.0.1 -d @-`,
+			`curl http://127.0.0.1 --data-binary @../a.txt`,
+			`curl http://127.0.0.1 -F file=@/etc/passwd`,
+		} {
+			_, err := FromCURL(cmd, WithCURLFileAccess(dir))
+			assert.True(t, errors.Is(err, errInvalidCURL), cmd)
+		}
+	})
+
+	t.Run("combined flags", func(t *testing.T) {
+		req, err := FromCURL(`curl -sSLk https://127.0.0.1 -XPOST`)
+		assert.NoError(t, err)
+		assert.Equal(t, req.method, http.MethodPost)
+		assert.True(t, req.client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
+
+		for _, cmd := range []string{`curl -sZ https://127.0.0.1`, `curl -sH https://127.0.0.1`} {
+			_, err = FromCURL(cmd)
+			assert.True(t, errors.Is(err, errInvalidCURL), cmd)
+		}
+	})
+
+	t.Run("insecure", func(t *testing.T) {
+		req, err := FromCURL(`curl -k -s https://127.0.0.1`)
+		assert.NoError(t, err)
+		assert.True(t, req.client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
+
+		cli, _ := NewClient(WithHTTPClient(&http.Client{Transport: http.NewFileTransport(http.Dir("."))}))
+		_, err = cli.FromCURL(`curl -k https://127.0.0.1`)
+		assert.True(t, errors.Is(err, errInvalidCURL))
+	})
+
+	t.Run("insecure with middlewares", func(t *testing.T) {
+		var srv = httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Write([]byte(request.Header.Get("X-Middleware")))
+		}))
+		defer srv.Close()
+
+		cli, _ := NewClient(WithDecompression(), WithTransportMiddleware(func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Set("X-Middleware", "1")
+				return next.RoundTrip(req)
+			})
+		}))
+		req, err := cli.FromCURL(`curl -k ` + srv.URL)
+		assert.NoError(t, err)
+		p, err := req.Send(nil).ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), "1")
+
+		req, _ = cli.FromCURL(`curl ` + srv.URL)
+		assert.Error(t, req.Send(nil).Err())
+	})
+
+	t.Run("compressed", func(t *testing.T) {
+		var body = compressBytes(t, []byte(`{"name":"caster"}`), CompressionGzip)
+		var srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if !strings.Contains(request.Header.Get("Accept-Encoding"), "gzip") {
+				writer.Write([]byte(`{"name":"identity"}`))
+				return
+			}
+			writer.Header().Set("Content-Encoding", "gzip")
+			writer.Write(body)
+		}))
+		defer srv.Close()
+
+		for _, cli := range []*Client{defaultClient, func() *Client { cli, _ := NewClient(WithDecompression()); return cli }()} {
+			req, err := cli.FromCURL(`curl ` + srv.URL + ` -H 'Accept-Encoding: gzip, deflate, br' --compressed`)
+			assert.NoError(t, err)
+			assert.Equal(t, req.headers.Get("Accept-Encoding"), "")
+			var v struct{ Name string }
+			assert.NoError(t, req.Send(nil).BindJSON(&v))
+			assert.Equal(t, v.Name, "caster")
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		for _, cmd := range []string{
+			`wget http://127.0.0.1`,
+			`curl`,
+			`curl http://127.0.0.1 --unknown`,
+			`curl http://127.0.0.1 -H`,
+			`curl http://127.0.0.1 http://127.0.0.2`,
+			`curl http://127.0.0.1 -b cookies.txt`,
+			`curl http://127.0.0.1 -F name`,
+			`curl 'http://127.0.0.1`,
+		} {
+			_, err := FromCURL(cmd)
+			assert.True(t, errors.Is(err, errInvalidCURL), cmd)
+		}
+
+		_, err := FromCURL(`curl http://127.0.0.1 --data-binary @not_exist.bin`, WithCURLFileAccess(t.TempDir()))
+		assert.Error(t, err)
+	})
+
+	t.Run("send", func(t *testing.T) {
+		addr := nextAddr()
+		srv := &http.Server{Addr: addr}
+		srv.Handler = http.Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			p, _ := io.ReadAll(request.Body)
+			writer.Header().Set("Content-Type", request.Header.Get("Content-Type"))
+			writer.WriteHeader(http.StatusOK)
+			writer.Write(p)
+		}))
+		go srv.ListenAndServe()
+		time.Sleep(100 * time.Millisecond)
+
+		req, err := FromCURL(`curl http://` + addr + ` -H 'Content-Type: application/json' -d '{"name":"caster"}'`)
+		assert.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			var input = struct{ Name string }{}
+			assert.NoError(t, req.Send(nil).BindJSON(&input))
+			assert.Equal(t, input.Name, "caster")
+		}
+
+		p, err := req.Send(strings.NewReader("hello")).ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), "hello")
+	})
+}
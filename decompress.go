@@ -0,0 +1,217 @@
+package hasaki
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// ErrDecompression 响应体解压失败, 通常是数据损坏或被截断; 读取响应体时的网络错误和上下文取消会原样返回
+// Failed to decompress the response body, usually because the data is corrupted or truncated; network errors and context cancellation while reading the body are returned as is
+var ErrDecompression = errors.New("decompression failed")
+
+type decompressor struct {
+	algos  map[string]bool
+	accept string
+}
+
+func newDecompressor(algos ...Compression) *decompressor {
+	if len(algos) == 0 {
+		algos = []Compression{CompressionGzip, CompressionDeflate, CompressionBrotli, CompressionZstd}
+	}
+	var c = &decompressor{algos: make(map[string]bool, len(algos))}
+	var list = make([]string, 0, len(algos))
+	for _, item := range algos {
+		if !c.algos[string(item)] {
+			c.algos[string(item)] = true
+			list = append(list, string(item))
+		}
+	}
+	c.accept = strings.Join(list, ", ")
+	return c
+}
+
+// middleware 未设置Accept-Encoding时声明支持的算法, 并按Content-Encoding解压响应体.
+// 用户手动设置Accept-Encoding或者使用Range请求时不声明, 但仍然解压支持的编码.
+func (c *decompressor) middleware(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" {
+			var r = *req
+			r.Header = req.Header.Clone()
+			r.Header.Set("Accept-Encoding", c.accept)
+			req = &r
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil || resp.Body == nil || resp.Body == http.NoBody {
+			return resp, err
+		}
+
+		var encodings = c.parse(resp.Header.Values("Content-Encoding"))
+		if len(encodings) == 0 {
+			return resp, nil
+		}
+		resp.Body = &decompressBody{body: resp.Body, encodings: encodings}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+		return resp, nil
+	})
+}
+
+// parse 返回解码顺序的编码列表(与Content-Encoding中的顺序相反); 含有不支持的编码时返回nil, 保持响应原样
+func (c *decompressor) parse(values []string) []string {
+	var encodings []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			var encoding = strings.ToLower(strings.TrimSpace(item))
+			if encoding == "" || encoding == "identity" {
+				continue
+			}
+			if encoding == "x-gzip" {
+				encoding = string(CompressionGzip)
+			}
+			if !c.algos[encoding] {
+				return nil
+			}
+			encodings = append([]string{encoding}, encodings...)
+		}
+	}
+	return encodings
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// decompressBody 首次读取时才创建解码器, 避免在RoundTrip中阻塞读取响应体
+type decompressBody struct {
+	body      io.ReadCloser
+	encodings []string
+	once      sync.Once
+	source    *sourceReader
+	reader    io.Reader
+	closers   []func()
+	err       error
+}
+
+func (c *decompressBody) init() {
+	c.source = &sourceReader{Reader: c.body}
+	c.reader = c.source
+	for _, encoding := range c.encodings {
+		var r io.Reader
+		var err error
+		switch encoding {
+		case string(CompressionGzip):
+			var gr *gzip.Reader
+			if gr, err = gzip.NewReader(c.reader); err == nil {
+				r = gr
+				c.closers = append(c.closers, func() { _ = gr.Close() })
+			}
+		case string(CompressionDeflate):
+			r, err = newDeflateReader(c.reader)
+		case string(CompressionBrotli):
+			r = brotli.NewReader(c.reader)
+		case string(CompressionZstd):
+			var zr *zstd.Decoder
+			if zr, err = zstd.NewReader(c.reader, zstd.WithDecoderConcurrency(1)); err == nil {
+				r = zr
+				c.closers = append(c.closers, zr.Close)
+			}
+		}
+		if err != nil {
+			c.err = c.source.wrap(encoding, err)
+			return
+		}
+		c.reader = &decompressReader{Reader: r, encoding: encoding, source: c.source}
+	}
+}
+
+func (c *decompressBody) Read(p []byte) (int, error) {
+	c.once.Do(c.init)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(p)
+}
+
+func (c *decompressBody) Close() error {
+	// 未读取就关闭时不再创建解码器, 之后的读取返回错误
+	c.once.Do(func() { c.err = http.ErrBodyReadAfterClose })
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		c.closers[i]()
+	}
+	c.closers = nil
+	return c.body.Close()
+}
+
+// decompressReader 将解码器产生的错误包装为ErrDecompression
+type decompressReader struct {
+	io.Reader
+	encoding string
+	source   *sourceReader
+}
+
+func (c *decompressReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	if err != nil && err != io.EOF && !errors.Is(err, ErrDecompression) {
+		err = c.source.wrap(c.encoding, err)
+	}
+	return n, err
+}
+
+// sourceReader 记录读取原始响应体时的错误, 网络错误和上下文取消不属于解压失败
+type sourceReader struct {
+	io.Reader
+	err error
+}
+
+func (c *sourceReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		c.err = err
+	}
+	return n, err
+}
+
+// wrap 只把解码器自身的错误包装为ErrDecompression, 其他错误原样返回
+func (c *sourceReader) wrap(encoding string, err error) error {
+	if c.err != nil && errors.Is(err, c.err) {
+		return err
+	}
+	var netErr net.Error
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return err
+	}
+	return decompressionError(encoding, err)
+}
+
+func decompressionError(encoding string, err error) error {
+	return errors.WithStack(fmt.Errorf("%w: %s: %w", ErrDecompression, encoding, err))
+}
+
+// newDeflateReader HTTP的deflate应为zlib格式, 但部分服务端发送不带zlib头的原始deflate数据, 根据前两个字节判断
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	var br = bufio.NewReader(r)
+	p, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(p) == 2 && p[0]&0x0f == 8 && (uint16(p[0])<<8|uint16(p[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
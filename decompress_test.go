@@ -0,0 +1,223 @@
+package hasaki
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/flate"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func compressBytes(t *testing.T, p []byte, algos ...Compression) []byte {
+	var encoder = NewStreamEncoder(MimeStream)
+	for _, algo := range algos {
+		encoder = NewCompressedEncoder(encoder, algo, WithCompressMinSize(0))
+	}
+	r, err := encoder.Encode(p)
+	assert.NoError(t, err)
+	result, _ := io.ReadAll(r)
+	return result
+}
+
+func TestWithDecompression(t *testing.T) {
+	var text = []byte(`{"name":"` + strings.Repeat("hasaki", 100) + `"}`)
+	var bodies = map[string][]byte{
+		"gzip":     compressBytes(t, text, CompressionGzip),
+		"x-gzip":   compressBytes(t, text, CompressionGzip),
+		"deflate":  compressBytes(t, text, CompressionDeflate),
+		"br":       compressBytes(t, text, CompressionBrotli),
+		"zstd":     compressBytes(t, text, CompressionZstd),
+		"gzip, br": compressBytes(t, text, CompressionGzip, CompressionBrotli),
+		"identity": text,
+	}
+	var raw = &strings.Builder{}
+	var fw, _ = flate.NewWriter(raw, flate.DefaultCompression)
+	fw.Write(text)
+	fw.Close()
+	bodies["deflate-raw"] = []byte(raw.String())
+
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var encoding = request.URL.Query().Get("encoding")
+		writer.Header().Set("X-Accept-Encoding", request.Header.Get("Accept-Encoding"))
+		writer.Header().Set("Content-Encoding", strings.TrimSuffix(encoding, "-raw"))
+		writer.Write(bodies[encoding])
+	})
+	go srv.ListenAndServe()
+	defer srv.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	var target = "http://" + addr
+
+	t.Run("bind", func(t *testing.T) {
+		cli, _ := NewClient(WithDecompression())
+		for encoding := range bodies {
+			var resp = cli.Get(target).SetQuery(url.Values{"encoding": {encoding}}).Send(nil)
+			assert.NoError(t, resp.Err())
+			assert.Equal(t, resp.Header.Get("X-Accept-Encoding"), "gzip, deflate, br, zstd")
+			if encoding != "identity" {
+				assert.Equal(t, resp.Header.Get("Content-Encoding"), "")
+				assert.True(t, resp.Uncompressed)
+			}
+
+			var v struct{ Name string }
+			assert.NoError(t, resp.BindJSON(&v))
+			assert.Equal(t, len(v.Name), 600)
+		}
+	})
+
+	t.Run("reuse body", func(t *testing.T) {
+		cli, _ := NewClient(WithDecompression(), WithReuseBody())
+		var resp = cli.Get(target).SetQuery(url.Values{"encoding": {"zstd"}}).Send(nil)
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, resp.Body.(BytesReadCloser).Bytes(), text)
+	})
+
+	t.Run("algos", func(t *testing.T) {
+		cli, _ := NewClient(WithDecompression(CompressionZstd, CompressionGzip, CompressionZstd))
+		var resp = cli.Get(target).SetQuery(url.Values{"encoding": {"br"}}).Send(nil)
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, resp.Header.Get("X-Accept-Encoding"), "zstd, gzip")
+		assert.Equal(t, resp.Header.Get("Content-Encoding"), "br")
+		p, _ := resp.ReadBody()
+		assert.Equal(t, p, bodies["br"])
+
+		resp = cli.Get(target).SetQuery(url.Values{"encoding": {"gzip, br"}}).Send(nil)
+		assert.Equal(t, resp.Header.Get("Content-Encoding"), "gzip, br")
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("accept encoding", func(t *testing.T) {
+		cli, _ := NewClient(WithDecompression())
+		var resp = cli.Get(target).SetQuery(url.Values{"encoding": {"gzip"}}).SetHeader("Accept-Encoding", "gzip").Send(nil)
+		assert.Equal(t, resp.Header.Get("X-Accept-Encoding"), "gzip")
+		p, _ := resp.ReadBody()
+		assert.Equal(t, p, text)
+
+		resp = cli.Get(target).SetQuery(url.Values{"encoding": {"identity"}}).SetHeader("Range", "bytes=0-").Send(nil)
+		assert.Equal(t, resp.Header.Get("X-Accept-Encoding"), "")
+		assert.NoError(t, resp.Body.Close())
+	})
+}
+
+func TestDecompression_Corrupted(t *testing.T) {
+	var text = []byte(strings.Repeat("hasaki", 1000))
+	var deflate = compressBytes(t, text, CompressionDeflate)
+	var bodies = map[string][]byte{
+		"gzip":    []byte("not gzip data"),
+		"deflate": deflate[:len(deflate)/2],
+		"br":      []byte("not brotli data"),
+		"zstd":    compressBytes(t, text, CompressionZstd)[:20],
+	}
+
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var encoding = request.URL.Query().Get("encoding")
+		writer.Header().Set("Content-Encoding", encoding)
+		writer.Write(bodies[encoding])
+	})
+	go srv.ListenAndServe()
+	defer srv.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	cli, _ := NewClient(WithDecompression())
+	for encoding := range bodies {
+		var resp = cli.Get("http://" + addr).SetQuery(url.Values{"encoding": {encoding}}).Send(nil)
+		assert.NoError(t, resp.Err())
+		_, err := resp.ReadBody()
+		assert.True(t, errors.Is(err, ErrDecompression), encoding)
+	}
+
+	t.Run("reuse body", func(t *testing.T) {
+		cli, _ := NewClient(WithDecompression(), WithReuseBody())
+		var resp = cli.Get("http://" + addr).SetQuery(url.Values{"encoding": {"gzip"}}).Send(nil)
+		assert.True(t, errors.Is(resp.Err(), ErrDecompression))
+	})
+}
+
+func TestDecompressBody_Close(t *testing.T) {
+	var body = &decompressBody{body: io.NopCloser(strings.NewReader("")), encodings: []string{"gzip"}}
+	assert.NoError(t, body.Close())
+	_, err := body.Read(make([]byte, 8))
+	assert.Equal(t, err, http.ErrBodyReadAfterClose)
+
+	var compressed = compressBytes(t, []byte("hello"), CompressionZstd, CompressionGzip)
+	body = &decompressBody{body: io.NopCloser(strings.NewReader(string(compressed))), encodings: []string{"gzip", "zstd"}}
+	p, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.Equal(t, string(p), "hello")
+	assert.NoError(t, body.Close())
+}
+
+type failingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (c *failingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if err == io.EOF {
+		err = c.err
+	}
+	return n, err
+}
+
+func TestDecompression_SourceError(t *testing.T) {
+	var compressed = compressBytes(t, []byte(strings.Repeat("hasaki", 1000)), CompressionGzip)
+	var netErr = &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+	var sourceErr = errors.New("source error")
+
+	for _, item := range []struct {
+		err      error
+		encoding string
+		data     []byte
+	}{
+		{err: context.Canceled, encoding: "gzip", data: compressed[:len(compressed)/2]},
+		{err: context.DeadlineExceeded, encoding: "zstd", data: nil},
+		{err: netErr, encoding: "gzip", data: compressed[:5]},
+		{err: sourceErr, encoding: "gzip", data: compressed[:len(compressed)/2]},
+		{err: sourceErr, encoding: "br", data: nil},
+	} {
+		var body = &decompressBody{
+			body:      io.NopCloser(&failingReader{r: bytes.NewReader(item.data), err: item.err}),
+			encodings: []string{item.encoding},
+		}
+		_, err := io.ReadAll(body)
+		assert.True(t, errors.Is(err, item.err), item.err.Error())
+		assert.False(t, errors.Is(err, ErrDecompression), item.err.Error())
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		addr := nextAddr()
+		srv := &http.Server{Addr: addr}
+		srv.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Content-Encoding", "gzip")
+			writer.Write(compressed[:len(compressed)/2])
+			writer.(http.Flusher).Flush()
+			time.Sleep(500 * time.Millisecond)
+			writer.Write(compressed[len(compressed)/2:])
+		})
+		go srv.ListenAndServe()
+		defer srv.Close()
+		time.Sleep(100 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		cli, _ := NewClient(WithDecompression())
+		var resp = cli.Get("http://" + addr).SetContext(ctx).Send(nil)
+		assert.NoError(t, resp.Err())
+		_, err := resp.ReadBody()
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.False(t, errors.Is(err, ErrDecompression))
+	})
+}
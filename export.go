@@ -0,0 +1,337 @@
+package hasaki
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lxzan/hasaki/internal"
+)
+
+// body超过该长度时不再输出到命令中
+const maxExportBodySize = 128 * 1024
+
+type (
+	exportConfig struct {
+		withoutRedaction bool
+	}
+
+	// ExportOption ToCURL, ToHTTPie和DumpRaw的配置
+	// Option of ToCURL, ToHTTPie and DumpRaw
+	ExportOption func(c *exportConfig)
+)
+
+// WithoutRedaction 导出原始内容, 不应用客户端的脱敏规则
+// Exporting the original content without applying the redaction rules of the client
+func WithoutRedaction() ExportOption {
+	return func(c *exportConfig) {
+		c.withoutRedaction = true
+	}
+}
+
+// exportRedactor 默认使用客户端的脱敏规则, 与Debug输出一致
+func (c *Request) exportRedactor(options []ExportOption) *Redactor {
+	var conf = new(exportConfig)
+	for _, f := range options {
+		f(conf)
+	}
+	if conf.withoutRedaction {
+		return nil
+	}
+	return c.redactor
+}
+
+// ToCURL 导出为CURL命令. body的编码方式与Send相同, 导出不会消费body; 无法重复读取的流以 --data-binary @- 表示.
+// 默认按客户端的脱敏规则隐藏敏感信息, 使用WithoutRedaction导出原始内容.
+// Export as a cURL command. The body is encoded the same way as Send and is not consumed;
+// streams that cannot be re-read are written as --data-binary @-.
+// Sensitive data is hidden by the redaction rules of the client, use WithoutRedaction to export the original content.
+func (c *Request) ToCURL(body any, options ...ExportOption) (string, error) {
+	req, err := c.newHTTPRequest(body)
+	if err != nil {
+		return "", err
+	}
+	defer closeEncodedBody(req)
+	return dumpCURL(req, c.exportRedactor(options), false), nil
+}
+
+// ToHTTPie 导出为HTTPie命令, 规则同ToCURL
+// Export as an HTTPie command, following the same rules as ToCURL
+func (c *Request) ToHTTPie(body any, options ...ExportOption) (string, error) {
+	req, err := c.newHTTPRequest(body)
+	if err != nil {
+		return "", err
+	}
+	defer closeEncodedBody(req)
+	return dumpHTTPie(req, c.exportRedactor(options), false), nil
+}
+
+// DumpRaw 导出为原始HTTP报文, 包含Go客户端会自动添加的请求头; 请求头按客户端的脱敏规则隐藏, 使用WithoutRedaction导出原始内容
+// Export as a raw HTTP message, including the headers the Go client adds automatically;
+// headers are hidden by the redaction rules of the client, use WithoutRedaction to export the original content
+func (c *Request) DumpRaw(body any, options ...ExportOption) (string, error) {
+	req, err := c.newHTTPRequest(body)
+	if err != nil {
+		return "", err
+	}
+	defer closeEncodedBody(req)
+
+	var snapshot = snapshotBody(req, false)
+	var data = snapshot.data
+	if snapshot.file != "" {
+		if data, err = os.ReadFile(snapshot.file); err != nil {
+			return "", err
+		}
+	}
+
+	var dumpBody = len(data) > 0 || snapshot.empty
+	var clone = req.Clone(req.Context())
+	clone.Header = c.exportRedactor(options).RedactHeader(req.Header)
+	clone.Body = io.NopCloser(bytes.NewReader(data))
+	if dumpBody {
+		clone.ContentLength = int64(len(data))
+	}
+	p, err := httputil.DumpRequestOut(clone, dumpBody)
+	return string(p), err
+}
+
+// 关闭编码器生成的池化缓冲区; 用户传入的Reader由用户自行管理
+func closeEncodedBody(req *http.Request) {
+	if v, ok := req.Body.(*internal.CloserWrapper); ok {
+		_ = v.Close()
+	}
+}
+
+type bodySnapshot struct {
+	empty  bool   // 没有body
+	data   []byte // 内存中的body
+	file   string // body为文件时的路径
+	stream bool   // 无法重复读取的流
+}
+
+func (c *bodySnapshot) binary() bool {
+	return isBinary(c.data)
+}
+
+func isBinary(p []byte) bool {
+	return !utf8.Valid(p) || bytes.IndexByte(p, 0) >= 0
+}
+
+// snapshotBody 获取body的副本而不消费它. consume为true时, 无法重复读取的流会被读入内存并替换req.Body.
+func snapshotBody(req *http.Request, consume bool) *bodySnapshot {
+	if req.Body == nil || req.Body == http.NoBody {
+		return &bodySnapshot{empty: true}
+	}
+
+	if v, ok := req.Body.(*os.File); ok {
+		return &bodySnapshot{file: v.Name()}
+	}
+
+	if v, ok := req.Body.(BytesReadCloser); ok {
+		return &bodySnapshot{data: v.Bytes()}
+	}
+
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			p, _ := io.ReadAll(rc)
+			_ = rc.Close()
+			return &bodySnapshot{data: p}
+		}
+	}
+
+	if !consume {
+		return &bodySnapshot{stream: true}
+	}
+
+	p, _ := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(p))
+	return &bodySnapshot{data: p}
+}
+
+// shellQuote 按照POSIX shell规则使用单引号转义
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+type multipartField struct {
+	name        string
+	value       string
+	filename    string
+	contentType string
+}
+
+func parseMultipart(contentType string, data []byte) ([]multipartField, bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, false
+	}
+
+	var fields []multipartField
+	var reader = multipart.NewReader(bytes.NewReader(data), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return fields, true
+		}
+		if err != nil {
+			return nil, false
+		}
+		var field = multipartField{name: part.FormName(), filename: part.FileName()}
+		if field.filename != "" {
+			field.contentType = part.Header.Get("Content-Type")
+		} else {
+			p, _ := io.ReadAll(part)
+			field.value = string(p)
+		}
+		_ = part.Close()
+		fields = append(fields, field)
+	}
+}
+
+// 支持透明解压的Accept-Encoding
+func isCompressedAccept(v string) bool {
+	for _, item := range strings.Split(v, ",") {
+		switch strings.TrimSpace(strings.SplitN(item, ";", 2)[0]) {
+		case "gzip", "deflate", "br", "zstd":
+			return true
+		}
+	}
+	return false
+}
+
+type exportHeader struct {
+	key   string
+	value string
+}
+
+func sortedHeaders(req *http.Request, redactor *Redactor) []exportHeader {
+	var header = redactor.RedactHeader(req.Header)
+	var keys = make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var list = make([]exportHeader, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range header[k] {
+			list = append(list, exportHeader{key: k, value: v})
+		}
+	}
+	return list
+}
+
+func dumpCURL(req *http.Request, redactor *Redactor, consume bool) string {
+	var snapshot = snapshotBody(req, consume)
+	var contentType = req.Header.Get("Content-Type")
+	var fields, isMultipart = parseMultipart(contentType, snapshot.data)
+
+	var command = "curl "
+	switch {
+	case req.Method == http.MethodHead:
+		command += "--head "
+	case req.Method != http.MethodGet || !snapshot.empty:
+		command += "-X " + req.Method + " "
+	}
+	var args = []string{command + shellQuote(redactor.RedactURL(req.URL.String()))}
+
+	for _, h := range sortedHeaders(req, redactor) {
+		switch {
+		case h.key == "Content-Length":
+			continue
+		case h.key == "Content-Type" && isMultipart:
+			continue
+		case h.key == "Accept-Encoding" && isCompressedAccept(h.value):
+			args = append(args, "--compressed")
+			continue
+		}
+		args = append(args, "--header "+shellQuote(h.key+": "+h.value))
+	}
+
+	switch {
+	case snapshot.empty:
+	case snapshot.file != "":
+		args = append(args, "--data-binary "+shellQuote("@"+snapshot.file))
+	case isMultipart:
+		for _, field := range fields {
+			if field.filename != "" {
+				var value = field.name + "=@" + field.filename
+				if field.contentType != "" {
+					value += ";type=" + field.contentType
+				}
+				args = append(args, "--form "+shellQuote(value))
+			} else {
+				args = append(args, "--form-string "+shellQuote(field.name+"="+field.value))
+			}
+		}
+	case snapshot.stream, snapshot.binary(), len(snapshot.data) > maxExportBodySize:
+		args = append(args, "--data-binary @-")
+	default:
+		var data = redactor.RedactBody(contentType, snapshot.data)
+		args = append(args, "--data-raw "+shellQuote(strings.TrimSuffix(string(data), "\n")))
+	}
+
+	return strings.Join(args, " \\\n    ")
+}
+
+func dumpHTTPie(req *http.Request, redactor *Redactor, consume bool) string {
+	var snapshot = snapshotBody(req, consume)
+	var contentType = req.Header.Get("Content-Type")
+	var fields, isMultipart = parseMultipart(contentType, snapshot.data)
+
+	var command = "http "
+	if snapshot.file == "" && !snapshot.stream && (isMultipart || !snapshot.binary() && len(snapshot.data) <= maxExportBodySize) {
+		command += "--ignore-stdin "
+	}
+	if isMultipart {
+		command += "--multipart "
+	}
+	var args = []string{command + req.Method + " " + shellQuote(redactor.RedactURL(req.URL.String()))}
+
+	for _, h := range sortedHeaders(req, redactor) {
+		switch {
+		case h.key == "Content-Length":
+			continue
+		case h.key == "Content-Type" && isMultipart:
+			continue
+		case h.key == "Accept-Encoding" && isCompressedAccept(h.value):
+			// HTTPie默认协商压缩并自动解压
+			continue
+		}
+		args = append(args, shellQuote(h.key+":"+h.value))
+	}
+
+	var redirect = ""
+	switch {
+	case snapshot.empty:
+	case snapshot.file != "":
+		redirect = " < " + shellQuote(snapshot.file)
+	case isMultipart:
+		for _, field := range fields {
+			if field.filename != "" {
+				var value = field.name + "@" + field.filename
+				if field.contentType != "" {
+					value += ";type=" + field.contentType
+				}
+				args = append(args, shellQuote(value))
+			} else {
+				args = append(args, shellQuote(field.name+"="+field.value))
+			}
+		}
+	case snapshot.stream, snapshot.binary(), len(snapshot.data) > maxExportBodySize:
+		// 从标准输入读取body
+	default:
+		var data = redactor.RedactBody(contentType, snapshot.data)
+		args = append(args, "--raw "+shellQuote(strings.TrimSuffix(string(data), "\n")))
+	}
+
+	return strings.Join(args, " \\\n    ") + redirect
+}
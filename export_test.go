@@ -0,0 +1,187 @@
+package hasaki
+
+import (
+	"bytes"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, shellQuote("abc"), `'abc'`)
+	assert.Equal(t, shellQuote("it's"), `'it'\''s'`)
+}
+
+func TestRequest_ToCURL(t *testing.T) {
+	t.Run("get", func(t *testing.T) {
+		cmd, err := Get("http://127.0.0.1/search").
+			SetHeader("Accept-Encoding", "gzip, deflate").
+			SetQuery("q=hasaki").
+			ToCURL(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, cmd, "curl 'http://127.0.0.1/search?q=hasaki' \\\n    --compressed")
+	})
+
+	t.Run("head", func(t *testing.T) {
+		cmd, err := Head("http://127.0.0.1").ToCURL(nil)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(cmd, "curl --head "))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		cmd, err := Post("http://127.0.0.1").
+			SetHeader("Authorization", "Bearer xxx").
+			ToCURL(Any{"name": "it's"})
+		assert.NoError(t, err)
+		assert.Equal(t, cmd, "curl -X POST 'http://127.0.0.1' \\\n"+
+			"    --header 'Authorization: ******' \\\n"+
+			"    --header 'Content-Type: application/json;charset=utf-8' \\\n"+
+			`    --data-raw '{"name":"it'\''s"}'`)
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		cmd, err := Put("http://127.0.0.1").
+			SetEncoder(NewStreamEncoder(MimeStream)).
+			ToCURL([]byte{0, 1, 2})
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(cmd, "--data-binary @-"))
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		var reader = strings.NewReader("hello")
+		var buf = bytes.NewBufferString("hello")
+		cmd, err := Put("http://127.0.0.1").
+			SetEncoder(NewStreamEncoder(MimeStream)).
+			ToCURL(struct{ *bytes.Buffer }{buf})
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(cmd, "--data-binary @-"))
+		assert.Equal(t, buf.String(), "hello")
+
+		cmd, err = Put("http://127.0.0.1").
+			SetEncoder(NewStreamEncoder(MimeStream)).
+			ToCURL(reader)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(cmd, "--data-raw 'hello'"))
+		assert.Equal(t, reader.Len(), 5)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		var filename = filepath.Join(t.TempDir(), "data.bin")
+		assert.NoError(t, os.WriteFile(filename, []byte("hello"), 0644))
+		file, _ := os.Open(filename)
+		defer file.Close()
+
+		cmd, err := Put("http://127.0.0.1").
+			SetEncoder(NewStreamEncoder(MimeStream)).
+			ToCURL(file)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(cmd, "--data-binary '@"+filename+"'"))
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		var buf = bytes.NewBuffer(nil)
+		var writer = multipart.NewWriter(buf)
+		_ = writer.WriteField("name", "caster")
+		part, _ := writer.CreateFormFile("avatar", "a.png")
+		_, _ = part.Write([]byte{0, 1, 2})
+		_ = writer.Close()
+
+		cmd, err := Post("http://127.0.0.1").
+			SetEncoder(NewStreamEncoder(writer.FormDataContentType())).
+			ToCURL(buf.Bytes())
+		assert.NoError(t, err)
+		assert.Equal(t, cmd, "curl -X POST 'http://127.0.0.1' \\\n"+
+			"    --form-string 'name=caster' \\\n"+
+			"    --form 'avatar=@a.png;type=application/octet-stream'")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Post("http://127.0.0.1").SetEncoder(FormCodec).ToCURL(123)
+		assert.Error(t, err)
+	})
+}
+
+func TestRequest_ToHTTPie(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		cmd, err := Post("http://127.0.0.1").ToHTTPie(Any{"name": "caster"})
+		assert.NoError(t, err)
+		assert.Equal(t, cmd, "http --ignore-stdin POST 'http://127.0.0.1' \\\n"+
+			"    'Content-Type:application/json;charset=utf-8' \\\n"+
+			`    --raw '{"name":"caster"}'`)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		var filename = filepath.Join(t.TempDir(), "data.bin")
+		assert.NoError(t, os.WriteFile(filename, []byte("hello"), 0644))
+		file, _ := os.Open(filename)
+		defer file.Close()
+
+		cmd, err := Put("http://127.0.0.1").
+			SetEncoder(NewStreamEncoder(MimeStream)).
+			ToHTTPie(file)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(cmd, " < '"+filename+"'"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Post("http://127.0.0.1").SetEncoder(FormCodec).ToHTTPie(123)
+		assert.Error(t, err)
+	})
+}
+
+func TestRequest_DumpRaw(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		raw, err := Post("http://127.0.0.1/api").DumpRaw(Any{"name": "caster"})
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(raw, "POST /api HTTP/1.1\r\nHost: 127.0.0.1\r\n"))
+		assert.True(t, strings.Contains(raw, "Content-Length: 18\r\n"))
+		assert.True(t, strings.HasSuffix(raw, "\r\n\r\n"+`{"name":"caster"}`+"\n"))
+	})
+
+	t.Run("file", func(t *testing.T) {
+		var filename = filepath.Join(t.TempDir(), "data.bin")
+		assert.NoError(t, os.WriteFile(filename, []byte("hello"), 0644))
+		file, _ := os.Open(filename)
+		defer file.Close()
+
+		raw, err := Put("http://127.0.0.1").
+			SetEncoder(NewStreamEncoder(MimeStream)).
+			DumpRaw(file)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(raw, "\r\n\r\nhello"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Post("http://127.0.0.1").SetEncoder(FormCodec).DumpRaw(123)
+		assert.Error(t, err)
+	})
+}
+
+func TestRequest_ExportRedaction(t *testing.T) {
+	var cli, _ = NewClient(WithRedactor(&Redactor{Headers: []string{"Authorization", "X-Api-Key"}, QueryKeys: []string{"token"}}))
+	var newRequest = func() *Request {
+		return cli.Get("http://127.0.0.1/api?token=abc").SetHeader("Authorization", "Bearer xxx").SetHeader("X-Api-Key", "secret")
+	}
+
+	for _, export := range []func(r *Request, options ...ExportOption) (string, error){
+		func(r *Request, options ...ExportOption) (string, error) { return r.ToCURL(nil, options...) },
+		func(r *Request, options ...ExportOption) (string, error) { return r.ToHTTPie(nil, options...) },
+		func(r *Request, options ...ExportOption) (string, error) { return r.DumpRaw(nil, options...) },
+	} {
+		output, err := export(newRequest())
+		assert.NoError(t, err)
+		assert.False(t, strings.Contains(output, "Bearer xxx"))
+		assert.False(t, strings.Contains(output, "secret"))
+		assert.True(t, strings.Contains(output, defaultRedactMask))
+
+		output, err = export(newRequest(), WithoutRedaction())
+		assert.NoError(t, err)
+		assert.True(t, strings.Contains(output, "Bearer xxx"))
+		assert.True(t, strings.Contains(output, "secret"))
+		assert.True(t, strings.Contains(output, "token=abc"))
+	}
+}
@@ -0,0 +1,241 @@
+package hasaki
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// FaultKind 故障类型
+// Kind of fault
+type FaultKind uint8
+
+const (
+	FaultLatency         FaultKind = iota // 发送请求前延迟Latency
+	FaultConnectionReset                  // 返回connection reset by peer错误, 不发送请求
+	FaultStatusCode                       // 返回StatusCode, 不发送请求
+	FaultTruncatedBody                    // 响应体只返回前TruncateAt个字节, 随后返回io.ErrUnexpectedEOF
+	FaultSlowBody                         // 每次读取响应体最多ChunkSize个字节, 每次读取前延迟Latency
+)
+
+// Fault 故障规则
+// A fault rule
+type Fault struct {
+	Kind        FaultKind
+	Probability float64                    // 触发概率, 取值[0, 1], 0表示从不触发
+	Match       func(r *http.Request) bool // 匹配请求, 为空时匹配所有请求
+	Latency     time.Duration              // FaultLatency和FaultSlowBody的延迟
+	StatusCode  int                        // FaultStatusCode返回的状态码
+	TruncateAt  int                        // FaultTruncatedBody保留的字节数
+	ChunkSize   int                        // FaultSlowBody每次读取的字节数, 默认为1
+}
+
+// MatchURL 返回一个匹配请求URL的函数, expr为正则表达式
+// Returns a function matching the request URL against the regular expression expr
+func MatchURL(expr string) func(r *http.Request) bool {
+	var re = regexp.MustCompile(expr)
+	return func(r *http.Request) bool {
+		return re.MatchString(r.URL.String())
+	}
+}
+
+// FaultInjector 故障注入器, 用于测试重试, 超时和降级逻辑. 规则和开关可以在运行时修改.
+// Fault injector for testing retry, timeout and fallback logic. Rules and the switch can be changed at runtime.
+type FaultInjector struct {
+	mu       sync.RWMutex
+	faults   []Fault
+	disabled atomic.Bool
+}
+
+// NewFaultInjector 创建故障注入器, 默认开启
+// Creating a fault injector, which is enabled by default
+func NewFaultInjector(faults ...Fault) *FaultInjector {
+	return &FaultInjector{faults: faults}
+}
+
+// SetFaults 替换全部故障规则
+// Replacing all fault rules
+func (c *FaultInjector) SetFaults(faults ...Fault) *FaultInjector {
+	c.mu.Lock()
+	c.faults = faults
+	c.mu.Unlock()
+	return c
+}
+
+// Add 添加故障规则
+// Adding a fault rule
+func (c *FaultInjector) Add(fault Fault) *FaultInjector {
+	c.mu.Lock()
+	c.faults = append(c.faults, fault)
+	c.mu.Unlock()
+	return c
+}
+
+// Enable 开启故障注入
+// Enabling fault injection
+func (c *FaultInjector) Enable() {
+	c.disabled.Store(false)
+}
+
+// Disable 关闭故障注入, 请求直接透传
+// Disabling fault injection, requests are passed through
+func (c *FaultInjector) Disable() {
+	c.disabled.Store(true)
+}
+
+// Enabled 是否开启故障注入
+// Whether fault injection is enabled
+func (c *FaultInjector) Enabled() bool {
+	return !c.disabled.Load()
+}
+
+// Middleware 返回注入故障的Transport中间件
+// Returns a transport middleware that injects faults
+func (c *FaultInjector) Middleware() TransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &faultTransport{injector: c, next: next}
+	}
+}
+
+// pick 返回本次请求命中的故障规则
+func (c *FaultInjector) pick(r *http.Request) []Fault {
+	if !c.Enabled() {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var results []Fault
+	for _, item := range c.faults {
+		if item.Match != nil && !item.Match(r) {
+			continue
+		}
+		if item.Probability >= 1 || (item.Probability > 0 && rand.Float64() < item.Probability) {
+			results = append(results, item)
+		}
+	}
+	return results
+}
+
+type faultTransport struct {
+	injector *FaultInjector
+	next     http.RoundTripper
+}
+
+func (c *faultTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	var faults = c.injector.pick(r)
+	for _, item := range faults {
+		switch item.Kind {
+		case FaultLatency:
+			if err := sleep(r.Context(), item.Latency); err != nil {
+				return nil, err
+			}
+		case FaultConnectionReset:
+			closeRequestBody(r)
+			return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+		case FaultStatusCode:
+			closeRequestBody(r)
+			var text = http.StatusText(item.StatusCode)
+			return &http.Response{
+				Status:        strconv.Itoa(item.StatusCode) + " " + text,
+				StatusCode:    item.StatusCode,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}},
+				Body:          io.NopCloser(strings.NewReader(text)),
+				ContentLength: int64(len(text)),
+				Request:       r,
+			}, nil
+		}
+	}
+
+	resp, err := c.next.RoundTrip(r)
+	if err != nil || resp.Body == nil {
+		return resp, err
+	}
+	for _, item := range faults {
+		switch item.Kind {
+		case FaultTruncatedBody:
+			resp.Body = &truncatedBody{ReadCloser: resp.Body, remain: item.TruncateAt}
+			resp.ContentLength = -1
+			resp.Header.Del("Content-Length")
+		case FaultSlowBody:
+			resp.Body = &slowBody{ReadCloser: resp.Body, ctx: r.Context(), latency: item.Latency, chunkSize: item.ChunkSize}
+		}
+	}
+	return resp, nil
+}
+
+func closeRequestBody(r *http.Request) {
+	if r.Body != nil {
+		_ = r.Body.Close()
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	var timer = time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type truncatedBody struct {
+	io.ReadCloser
+	remain int
+}
+
+func (c *truncatedBody) Read(p []byte) (int, error) {
+	if c.remain <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if len(p) > c.remain {
+		p = p[:c.remain]
+	}
+	n, err := c.ReadCloser.Read(p)
+	c.remain -= n
+	if err == io.EOF {
+		return n, err
+	}
+	if c.remain <= 0 && err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+type slowBody struct {
+	io.ReadCloser
+	ctx       context.Context
+	latency   time.Duration
+	chunkSize int
+}
+
+func (c *slowBody) Read(p []byte) (int, error) {
+	if err := sleep(c.ctx, c.latency); err != nil {
+		return 0, err
+	}
+	var size = c.chunkSize
+	if size <= 0 {
+		size = 1
+	}
+	if len(p) > size {
+		p = p[:size]
+	}
+	return c.ReadCloser.Read(p)
+}
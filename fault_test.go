@@ -0,0 +1,97 @@
+package hasaki
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultInjector(t *testing.T) {
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+		writer.Write([]byte("hello world"))
+	}))
+	go srv.ListenAndServe()
+	time.Sleep(100 * time.Millisecond)
+
+	var injector = NewFaultInjector()
+	var cli, _ = NewClient(WithFaultInjector(injector), WithHTTPClient(&http.Client{}))
+
+	t.Run("status code", func(t *testing.T) {
+		injector.SetFaults(Fault{Kind: FaultStatusCode, Probability: 1, StatusCode: http.StatusServiceUnavailable, Match: MatchURL("/users$")})
+		var resp = cli.Get("http://%s/users", addr).Send(nil)
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, resp.StatusCode, http.StatusServiceUnavailable)
+
+		resp = cli.Get("http://%s/orders", addr).Send(nil)
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, resp.StatusCode, http.StatusOK)
+	})
+
+	t.Run("connection reset", func(t *testing.T) {
+		injector.SetFaults(Fault{Kind: FaultConnectionReset, Probability: 1})
+		var err = cli.Post("http://%s", addr).Send(Any{"name": "caster"}).Err()
+		assert.True(t, errors.Is(err, syscall.ECONNRESET))
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		injector.SetFaults(Fault{Kind: FaultLatency, Probability: 1, Latency: time.Second})
+		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		var err = cli.Get("http://%s", addr).SetContext(ctx).Send(nil).Err()
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("truncated body", func(t *testing.T) {
+		injector.SetFaults(Fault{Kind: FaultTruncatedBody, Probability: 1, TruncateAt: 5})
+		var resp = cli.Get("http://%s", addr).Send(nil)
+		assert.NoError(t, resp.Err())
+		p, err := io.ReadAll(resp.Body)
+		assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+		assert.Equal(t, string(p), "hello")
+	})
+
+	t.Run("slow body", func(t *testing.T) {
+		injector.SetFaults(Fault{Kind: FaultSlowBody, Probability: 1, Latency: 5 * time.Millisecond, ChunkSize: 4})
+		var t0 = time.Now()
+		p, err := cli.Get("http://%s", addr).Send(nil).ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), "hello world")
+		assert.GreaterOrEqual(t, time.Since(t0), 15*time.Millisecond)
+	})
+
+	t.Run("switch", func(t *testing.T) {
+		injector.SetFaults(Fault{Kind: FaultConnectionReset, Probability: 0})
+		assert.NoError(t, cli.Get("http://%s", addr).Send(nil).Err())
+
+		injector.SetFaults().Add(Fault{Kind: FaultConnectionReset, Probability: 1})
+		injector.Disable()
+		assert.False(t, injector.Enabled())
+		assert.NoError(t, cli.Get("http://%s", addr).Send(nil).Err())
+
+		injector.Enable()
+		assert.Error(t, cli.Get("http://%s", addr).Send(nil).Err())
+	})
+
+	t.Run("probability", func(t *testing.T) {
+		injector.SetFaults(Fault{Kind: FaultStatusCode, Probability: 0.5, StatusCode: http.StatusBadGateway})
+		var count = 0
+		for i := 0; i < 200; i++ {
+			var resp = cli.Get("http://%s", addr).Send(nil)
+			if resp.StatusCode == http.StatusBadGateway {
+				count++
+			}
+			_, _ = resp.ReadBody()
+		}
+		assert.Greater(t, count, 50)
+		assert.Less(t, count, 150)
+	})
+}
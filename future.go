@@ -0,0 +1,74 @@
+package hasaki
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// ErrPanicRecovered SendAsync中发生的panic被恢复后返回的错误
+// The error returned when a panic in SendAsync is recovered
+var ErrPanicRecovered = errors.New("panic recovered")
+
+// Future 异步请求的结果
+// The result of an asynchronous request
+type Future struct {
+	done chan struct{}
+	resp *Response
+}
+
+// SendAsync 在新的goroutine中发送请求; 通过请求上下文取消.
+// 中间件和编解码器中的panic会被恢复并转换为ErrPanicRecovered错误.
+// Sends the request in a new goroutine; cancel it through the request context.
+// Panics in middlewares and codecs are recovered and turned into ErrPanicRecovered errors.
+func (c *Request) SendAsync(body any) *Future {
+	var f = &Future{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		defer func() {
+			if e := recover(); e != nil {
+				f.resp = &Response{ctx: c.ctx, err: errors.Wrapf(ErrPanicRecovered, "%v", e)}
+			}
+		}()
+		f.resp = c.Send(body)
+	}()
+	return f
+}
+
+// Done 返回一个在请求完成后关闭的通道
+// Returns a channel that is closed when the request completes
+func (c *Future) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait 阻塞直到请求完成, 返回响应
+// Blocks until the request completes and returns the response
+func (c *Future) Wait() *Response {
+	<-c.done
+	return c.resp
+}
+
+// WaitAll 等待所有请求完成, 按参数顺序返回响应
+// Waits for all requests to complete, returning responses in argument order
+func WaitAll(futures ...*Future) []*Response {
+	var results = make([]*Response, len(futures))
+	for i, f := range futures {
+		results[i] = f.Wait()
+	}
+	return results
+}
+
+// WaitAny 等待任意一个请求完成, 返回其下标和响应; 没有参数时返回-1和nil
+// Waits for any request to complete, returning its index and response; returns -1 and nil if there are no futures
+func WaitAny(futures ...*Future) (int, *Response) {
+	if len(futures) == 0 {
+		return -1, nil
+	}
+	// 使用reflect.Select, 不需要为每个请求启动goroutine
+	var cases = make([]reflect.SelectCase, len(futures))
+	for i, f := range futures {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(f.done)}
+	}
+	index, _, _ := reflect.Select(cases)
+	return index, futures[index].resp
+}
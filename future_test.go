@@ -0,0 +1,95 @@
+package hasaki
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest_SendAsync(t *testing.T) {
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if d, err := time.ParseDuration(request.URL.Query().Get("sleep")); err == nil {
+			time.Sleep(d)
+		}
+		writer.Write([]byte(request.URL.Query().Get("sleep")))
+	}))
+	go srv.ListenAndServe()
+	time.Sleep(100 * time.Millisecond)
+
+	var cli, _ = NewClient()
+
+	t.Run("wait", func(t *testing.T) {
+		var f = cli.Get("http://%s?sleep=10ms", addr).SendAsync(nil)
+		select {
+		case <-f.Done():
+			t.Fatal("should not be done")
+		default:
+		}
+		p, err := f.Wait().ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), "10ms")
+		<-f.Done()
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		var ctx, cancel = context.WithCancel(context.Background())
+		var f = cli.Get("http://%s?sleep=1s", addr).SetContext(ctx).SendAsync(nil)
+		cancel()
+		assert.True(t, errors.Is(f.Wait().Err(), context.Canceled))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		var f = cli.Get("http://%s", addr).
+			SetBefore(func(ctx context.Context, request *http.Request) (context.Context, error) {
+				panic("oops")
+			}).
+			SendAsync(nil)
+		var err = f.Wait().Err()
+		assert.True(t, errors.Is(err, ErrPanicRecovered))
+		assert.Contains(t, err.Error(), "oops")
+	})
+
+	t.Run("wait all", func(t *testing.T) {
+		var results = WaitAll(
+			cli.Get("http://%s?sleep=30ms", addr).SendAsync(nil),
+			cli.Get("http://%s?sleep=1ms", addr).SendAsync(nil),
+		)
+		assert.Equal(t, len(results), 2)
+		for i, expected := range []string{"30ms", "1ms"} {
+			p, err := results[i].ReadBody()
+			assert.NoError(t, err)
+			assert.Equal(t, string(p), expected)
+		}
+	})
+
+	t.Run("wait any", func(t *testing.T) {
+		index, resp := WaitAny(
+			cli.Get("http://%s?sleep=500ms", addr).SendAsync(nil),
+			cli.Get("http://%s?sleep=1ms", addr).SendAsync(nil),
+		)
+		assert.Equal(t, index, 1)
+		assert.NoError(t, resp.Err())
+
+		index, resp = WaitAny()
+		assert.Equal(t, index, -1)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("wait any without goroutines", func(t *testing.T) {
+		var pending = &Future{done: make(chan struct{})}
+		var done = &Future{done: make(chan struct{}), resp: &Response{}}
+		close(done.done)
+		var n = runtime.NumGoroutine()
+		index, resp := WaitAny(pending, pending, done)
+		assert.Equal(t, index, 2)
+		assert.Equal(t, resp, done.resp)
+		assert.Equal(t, runtime.NumGoroutine(), n)
+	})
+}
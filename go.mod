@@ -1,12 +1,15 @@
 module github.com/lxzan/hasaki
 
-go 1.18
+go 1.21
 
 require (
+	github.com/andybalholm/brotli v1.1.0
 	github.com/json-iterator/go v1.1.12
+	github.com/klauspost/compress v1.17.9
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.8.4
 	github.com/valyala/bytebufferpool v1.0.0
+	golang.org/x/text v0.14.0
 )
 
 require (
@@ -0,0 +1,302 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lxzan/hasaki"
+	"github.com/pkg/errors"
+)
+
+var errUnexpectedStatus = errors.New("unexpected status code")
+
+// 自动持久化查询(APQ)未命中时服务端返回的错误
+const (
+	persistedQueryNotFound     = "PersistedQueryNotFound"
+	persistedQueryNotFoundCode = "PERSISTED_QUERY_NOT_FOUND"
+)
+
+type (
+	config struct {
+		persistedQueries bool
+	}
+
+	Option func(c *config)
+)
+
+// WithPersistedQueries 开启自动持久化查询(APQ), 先只发送查询的sha256哈希, 服务端未缓存时再发送完整查询
+// Enabling automatic persisted queries (APQ): only the sha256 hash of the query is sent first, the full query is sent when the server has not cached it
+func WithPersistedQueries() Option {
+	return func(c *config) {
+		c.persistedQueries = true
+	}
+}
+
+// Client GraphQL客户端, 请求通过hasaki.Client发送, 中间件照常生效
+// GraphQL client; requests are sent through hasaki.Client so middlewares apply
+type Client struct {
+	conf   *config
+	client *hasaki.Client
+	url    string
+}
+
+// NewClient 创建GraphQL客户端, url为GraphQL端点
+// Creating a GraphQL client, url is the GraphQL endpoint
+func NewClient(client *hasaki.Client, url string, options ...Option) *Client {
+	var conf = &config{}
+	for _, f := range options {
+		f(conf)
+	}
+	return &Client{conf: conf, client: client, url: url}
+}
+
+type (
+	// Request GraphQL查询或者变更
+	// A GraphQL query or mutation
+	Request struct {
+		query         string
+		operationName string
+		variables     map[string]any
+		headers       http.Header
+		files         []file
+	}
+
+	file struct {
+		path     string
+		filename string
+		reader   io.Reader
+	}
+)
+
+// NewRequest 创建请求, query可以是查询或者变更
+// Creating a request, query can be a query or a mutation
+func NewRequest(query string) *Request {
+	return &Request{query: query, variables: map[string]any{}, headers: http.Header{}}
+}
+
+// Var 设置变量
+// Setting a variable
+func (c *Request) Var(key string, value any) *Request {
+	c.variables[key] = value
+	return c
+}
+
+// SetOperationName 设置操作名称, 文档包含多个操作时必须设置
+// Setting the operation name, required when the document contains multiple operations
+func (c *Request) SetOperationName(name string) *Request {
+	c.operationName = name
+	return c
+}
+
+// SetHeader 设置请求头
+// Setting a request header
+func (c *Request) SetHeader(k, v string) *Request {
+	c.headers.Set(k, v)
+	return c
+}
+
+// File 按照GraphQL multipart请求规范上传文件, path为变量路径, 例如 file 或者 files.0
+// Uploading a file as described by the GraphQL multipart request spec, path is the variable path, e.g. file or files.0
+func (c *Request) File(path string, filename string, reader io.Reader) *Request {
+	c.files = append(c.files, file{path: path, filename: filename, reader: reader})
+	return c
+}
+
+type (
+	payload struct {
+		Query         string         `json:"query,omitempty"`
+		OperationName string         `json:"operationName,omitempty"`
+		Variables     map[string]any `json:"variables,omitempty"`
+		Extensions    *extensions    `json:"extensions,omitempty"`
+	}
+
+	extensions struct {
+		PersistedQuery *persistedQuery `json:"persistedQuery,omitempty"`
+	}
+
+	persistedQuery struct {
+		Version    int    `json:"version"`
+		Sha256Hash string `json:"sha256Hash"`
+	}
+
+	response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors Errors          `json:"errors"`
+	}
+)
+
+// Do 执行请求并将data解码到v; v为nil时忽略data.
+// 响应包含errors时返回Errors, 此时部分data仍会被解码.
+// Executes the request and decodes data into v; data is ignored when v is nil.
+// Returns Errors when the response contains errors, in which case partial data is still decoded.
+func (c *Client) Do(ctx context.Context, request *Request, v any) error {
+	var body = payload{
+		Query:         request.query,
+		OperationName: request.operationName,
+		Variables:     request.variables,
+	}
+
+	var resp *response
+	var err error
+	if c.conf.persistedQueries && len(request.files) == 0 {
+		var hash = sha256.Sum256([]byte(request.query))
+		body.Extensions = &extensions{PersistedQuery: &persistedQuery{Version: 1, Sha256Hash: hex.EncodeToString(hash[:])}}
+		body.Query = ""
+		if resp, err = c.send(ctx, request, body); err != nil {
+			return err
+		}
+		if resp.Errors.persistedQueryNotFound() {
+			body.Query = request.query
+			resp, err = c.send(ctx, request, body)
+		}
+	} else {
+		resp, err = c.send(ctx, request, body)
+	}
+	if err != nil {
+		return err
+	}
+
+	if v != nil && len(resp.Data) > 0 && string(resp.Data) != "null" {
+		if err := hasaki.JsonCodec.Decode(bytes.NewReader(resp.Data), v); err != nil {
+			return err
+		}
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
+
+func (c *Client) send(ctx context.Context, request *Request, body payload) (*response, error) {
+	var req = c.client.Post(c.url).SetContext(ctx).SetHeaders(request.headers.Clone())
+	var res *hasaki.Response
+	if len(request.files) > 0 {
+		p, contentType, err := encodeMultipart(body, request.files)
+		if err != nil {
+			return nil, err
+		}
+		res = req.SetEncoder(hasaki.NewStreamEncoder(contentType)).Send(p)
+	} else {
+		res = req.Send(body)
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+
+	p, err := res.ReadBody()
+	if err != nil {
+		return nil, err
+	}
+	var resp = &response{}
+	if err := hasaki.JsonCodec.Decode(bytes.NewReader(p), resp); err != nil || (resp.Data == nil && resp.Errors == nil) {
+		if res.StatusCode >= http.StatusBadRequest {
+			return nil, errors.Wrapf(errUnexpectedStatus, "%d", res.StatusCode)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// encodeMultipart 按照GraphQL multipart请求规范编码, 文件对应的变量被置为null
+func encodeMultipart(body payload, files []file) ([]byte, string, error) {
+	var variables = cloneValue(body.Variables).(map[string]any)
+	var fileMap = make(map[string][]string, len(files))
+	for i, item := range files {
+		setPath(variables, strings.Split(item.path, "."))
+		fileMap[strconv.Itoa(i)] = []string{"variables." + item.path}
+	}
+	body.Variables = variables
+
+	var buf = bytes.NewBuffer(nil)
+	var writer = multipart.NewWriter(buf)
+	operations, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	if err := writer.WriteField("operations", string(operations)); err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	mapping, err := json.Marshal(fileMap)
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	if err := writer.WriteField("map", string(mapping)); err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	for i, item := range files {
+		part, err := writer.CreateFormFile(strconv.Itoa(i), item.filename)
+		if err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+		if _, err := io.Copy(part, item.reader); err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// setPath 将path指向的变量置为null, 必要时创建中间的对象或者数组
+func setPath(data map[string]any, path []string) {
+	var key = path[0]
+	if len(path) == 1 {
+		data[key] = nil
+		return
+	}
+	if index, err := strconv.Atoi(path[1]); err == nil {
+		list, _ := data[key].([]any)
+		for len(list) <= index {
+			list = append(list, nil)
+		}
+		if len(path) == 2 {
+			list[index] = nil
+		} else {
+			child, ok := list[index].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+			}
+			setPath(child, path[2:])
+			list[index] = child
+		}
+		data[key] = list
+		return
+	}
+	child, ok := data[key].(map[string]any)
+	if !ok {
+		child = map[string]any{}
+	}
+	setPath(child, path[1:])
+	data[key] = child
+}
+
+// cloneValue 深拷贝变量中的map和slice, 避免修改调用方的数据
+func cloneValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		var m = make(map[string]any, len(value))
+		for k, item := range value {
+			m[k] = cloneValue(item)
+		}
+		return m
+	case []any:
+		var list = make([]any, len(value))
+		for i, item := range value {
+			list[i] = cloneValue(item)
+		}
+		return list
+	default:
+		return v
+	}
+}
@@ -0,0 +1,159 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lxzan/hasaki"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	var mu sync.Mutex
+	var cache = map[string]bool{}
+	var requests []payload
+
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var body payload
+		if strings.HasPrefix(request.Header.Get("Content-Type"), "multipart/form-data") {
+			assert.NoError(t, json.Unmarshal([]byte(request.FormValue("operations")), &body))
+			var fileMap map[string][]string
+			assert.NoError(t, json.Unmarshal([]byte(request.FormValue("map")), &fileMap))
+			var names []string
+			for key, paths := range fileMap {
+				f, header, err := request.FormFile(key)
+				assert.NoError(t, err)
+				p, _ := io.ReadAll(f)
+				names = append(names, paths[0]+"="+header.Filename+":"+string(p))
+			}
+			writer.Write([]byte(`{"data":{"upload":"` + strings.Join(names, ",") + `"}}`))
+			return
+		}
+
+		assert.NoError(t, json.NewDecoder(request.Body).Decode(&body))
+		mu.Lock()
+		requests = append(requests, body)
+		if body.Extensions != nil {
+			var hash = body.Extensions.PersistedQuery.Sha256Hash
+			if body.Query == "" && !cache[hash] {
+				mu.Unlock()
+				writer.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`))
+				return
+			}
+			cache[hash] = true
+		}
+		mu.Unlock()
+
+		switch {
+		case strings.Contains(body.Query, "broken"), body.OperationName == "Broken":
+			writer.WriteHeader(http.StatusInternalServerError)
+			writer.Write([]byte("internal error"))
+		case strings.Contains(body.Query, "fail"):
+			writer.Write([]byte(`{"data":{"user":{"name":"caster","email":null}},"errors":[{"message":"forbidden","locations":[{"line":1,"column":20}],"path":["user","email"],"extensions":{"code":"FORBIDDEN"}}]}`))
+		default:
+			writer.Write([]byte(`{"data":{"user":{"id":"` + body.Variables["id"].(string) + `","name":"caster","op":"` + body.OperationName + `"}}}`))
+		}
+	}))
+	defer srv.Close()
+
+	type result struct {
+		User struct {
+			Id   string  `json:"id"`
+			Name string  `json:"name"`
+			Op   string  `json:"op"`
+			Mail *string `json:"email"`
+		} `json:"user"`
+	}
+
+	cli, _ := hasaki.NewClient()
+
+	t.Run("query", func(t *testing.T) {
+		var client = NewClient(cli, srv.URL)
+		var data result
+		var err = client.Do(context.Background(), NewRequest(`query GetUser($id: ID!) { user(id: $id) { id name } }`).
+			Var("id", "1").
+			SetOperationName("GetUser").
+			SetHeader("Authorization", "Bearer xxx"), &data)
+		assert.NoError(t, err)
+		assert.Equal(t, data.User.Id, "1")
+		assert.Equal(t, data.User.Op, "GetUser")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var client = NewClient(cli, srv.URL)
+		var data result
+		var err = client.Do(context.Background(), NewRequest(`query { user { name email } } # fail`), &data)
+		assert.Equal(t, data.User.Name, "caster")
+
+		var errs Errors
+		assert.True(t, errors.As(err, &errs))
+		assert.Equal(t, len(errs), 1)
+		assert.Equal(t, errs[0].Path, []any{"user", "email"})
+		assert.Equal(t, errs[0].Locations, []Location{{Line: 1, Column: 20}})
+		assert.Equal(t, errs[0].Code(), "FORBIDDEN")
+		assert.Equal(t, err.Error(), "graphql: user.email: forbidden")
+
+		var e *Error
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, e.Message, "forbidden")
+
+		err = client.Do(context.Background(), NewRequest(`query { broken }`), nil)
+		assert.True(t, errors.Is(err, errUnexpectedStatus))
+	})
+
+	t.Run("persisted queries", func(t *testing.T) {
+		mu.Lock()
+		requests = nil
+		mu.Unlock()
+		var client = NewClient(cli, srv.URL, WithPersistedQueries())
+		var query = `query GetUser($id: ID!) { user(id: $id) { id } }`
+		for i := 0; i < 2; i++ {
+			var data result
+			assert.NoError(t, client.Do(context.Background(), NewRequest(query).Var("id", "2"), &data))
+			assert.Equal(t, data.User.Id, "2")
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, len(requests), 3)
+		assert.Equal(t, requests[0].Query, "")
+		assert.Equal(t, requests[1].Query, query)
+		assert.Equal(t, requests[2].Query, "")
+		assert.Equal(t, requests[2].Extensions.PersistedQuery.Sha256Hash, requests[0].Extensions.PersistedQuery.Sha256Hash)
+	})
+
+	t.Run("upload", func(t *testing.T) {
+		var client = NewClient(cli, srv.URL)
+		var variables = map[string]any{"id": "1"}
+		var data struct {
+			Upload string `json:"upload"`
+		}
+		var err = client.Do(context.Background(), NewRequest(`mutation ($file: Upload!) { upload(file: $file) }`).
+			Var("input", variables).
+			File("input.avatar", "a.txt", strings.NewReader("hello")), &data)
+		assert.NoError(t, err)
+		assert.Equal(t, data.Upload, "variables.input.avatar=a.txt:hello")
+		assert.Equal(t, variables, map[string]any{"id": "1"})
+	})
+}
+
+func TestSetPath(t *testing.T) {
+	var data = map[string]any{"files": []any{"a"}, "input": map[string]any{"name": "caster"}}
+	setPath(data, []string{"file"})
+	setPath(data, []string{"files", "1"})
+	setPath(data, []string{"input", "avatar"})
+	setPath(data, []string{"list", "0", "file"})
+	assert.Equal(t, data, map[string]any{
+		"file":  nil,
+		"files": []any{"a", nil},
+		"input": map[string]any{"name": "caster", "avatar": nil},
+		"list":  []any{map[string]any{"file": nil}},
+	})
+}
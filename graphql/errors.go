@@ -0,0 +1,73 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// Location 错误在查询文档中的位置
+	// Location of the error in the query document
+	Location struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	}
+
+	// Error GraphQL响应中的一个错误
+	// An error in the GraphQL response
+	Error struct {
+		Message    string         `json:"message"`
+		Locations  []Location     `json:"locations,omitempty"`
+		Path       []any          `json:"path,omitempty"`
+		Extensions map[string]any `json:"extensions,omitempty"`
+	}
+
+	// Errors GraphQL响应中的errors数组
+	// The errors array of the GraphQL response
+	Errors []*Error
+)
+
+func (c *Error) Error() string {
+	if len(c.Path) == 0 {
+		return c.Message
+	}
+	var path = make([]string, len(c.Path))
+	for i, item := range c.Path {
+		path[i] = fmt.Sprint(item)
+	}
+	return strings.Join(path, ".") + ": " + c.Message
+}
+
+// Code 返回extensions.code
+// Returns extensions.code
+func (c *Error) Code() string {
+	code, _ := c.Extensions["code"].(string)
+	return code
+}
+
+func (c Errors) Error() string {
+	var messages = make([]string, len(c))
+	for i, item := range c {
+		messages[i] = item.Error()
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// Unwrap 支持errors.As匹配单个*Error
+// Supports matching a single *Error with errors.As
+func (c Errors) Unwrap() []error {
+	var results = make([]error, len(c))
+	for i, item := range c {
+		results[i] = item
+	}
+	return results
+}
+
+func (c Errors) persistedQueryNotFound() bool {
+	for _, item := range c {
+		if item.Message == persistedQueryNotFound || item.Code() == persistedQueryNotFoundCode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,418 @@
+package hasaki
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lxzan/hasaki/internal"
+	"github.com/pkg/errors"
+)
+
+const (
+	harVersion          = "1.2"
+	harCreatorName      = "hasaki"
+	harCreatorVersion   = "1.0"
+	defaultHARBodyLimit = 64 * 1024
+)
+
+type (
+	harDocument struct {
+		Log harLog `json:"log"`
+	}
+
+	harLog struct {
+		Version string      `json:"version"`
+		Creator harCreator  `json:"creator"`
+		Entries []*harEntry `json:"entries"`
+	}
+
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	harEntry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+		Connection      string      `json:"connection,omitempty"`
+		Error           string      `json:"_error,omitempty"`
+	}
+
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harCookie    `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int64          `json:"bodySize"`
+	}
+
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+		Comment  string `json:"comment,omitempty"`
+	}
+
+	harResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harCookie    `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		Content     harBody        `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int64          `json:"bodySize"`
+	}
+
+	harBody struct {
+		Size     int64  `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+		Encoding string `json:"encoding,omitempty"`
+		Comment  string `json:"comment,omitempty"`
+	}
+
+	harCookie struct {
+		Name     string `json:"name"`
+		Value    string `json:"value"`
+		Path     string `json:"path,omitempty"`
+		Domain   string `json:"domain,omitempty"`
+		Expires  string `json:"expires,omitempty"`
+		HTTPOnly bool   `json:"httpOnly,omitempty"`
+		Secure   bool   `json:"secure,omitempty"`
+	}
+
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	harTimings struct {
+		Blocked float64 `json:"blocked"`
+		DNS     float64 `json:"dns"`
+		Connect float64 `json:"connect"`
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+		SSL     float64 `json:"ssl"`
+	}
+)
+
+// HARRecorder 以HAR 1.2格式记录客户端发出的请求和收到的响应, 敏感信息会按照客户端的脱敏规则隐藏
+// Records requests and responses of a client in HAR 1.2 format; sensitive data is hidden according to the client's redaction rules
+type HARRecorder struct {
+	mu        sync.Mutex
+	w         io.Writer
+	bodyLimit int
+	entries   []*harEntry
+}
+
+// NewHARRecorder 新建一个HAR记录器, Flush时写入w
+// Create a HAR recorder which writes to w when flushed
+func NewHARRecorder(w io.Writer) *HARRecorder {
+	return &HARRecorder{w: w, bodyLimit: defaultHARBodyLimit}
+}
+
+// SetBodyLimit 设置记录的body最大字节数, 默认64KB
+// Set the maximum number of body bytes to record, 64KB by default
+func (c *HARRecorder) SetBodyLimit(n int) *HARRecorder {
+	c.bodyLimit = n
+	return c
+}
+
+// Len 返回尚未写入的记录条数
+// Returns the number of entries that have not been flushed
+func (c *HARRecorder) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Flush 将已记录的请求写入为一个完整的HAR文档, 并清空记录
+// Write the recorded entries as a complete HAR document and reset the recorder
+func (c *HARRecorder) Flush() error {
+	c.mu.Lock()
+	var entries = c.entries
+	c.entries = nil
+	c.mu.Unlock()
+
+	if entries == nil {
+		entries = make([]*harEntry, 0)
+	}
+	var doc = harDocument{Log: harLog{
+		Version: harVersion,
+		Creator: harCreator{Name: harCreatorName, Version: harCreatorVersion},
+		Entries: entries,
+	}}
+	var encoder = json.NewEncoder(c.w)
+	encoder.SetIndent("", "  ")
+	return errors.WithStack(encoder.Encode(doc))
+}
+
+func (c *HARRecorder) add(entry *harEntry) {
+	c.mu.Lock()
+	c.entries = append(c.entries, entry)
+	c.mu.Unlock()
+}
+
+// harSession 记录单次请求的状态
+type harSession struct {
+	recorder *HARRecorder
+	redactor *Redactor
+	trace    *traceCollector
+	entry    *harEntry
+
+	mu       sync.Mutex // 保护流式请求body的记录, Transport在另一个goroutine中读取请求body
+	postData *harPostData
+	postSize int64
+}
+
+// begin 在发送请求前记录请求信息; 不可重复读取的流在Transport发送时记录前bodyLimit个字节, 不会读入内存
+func (c *HARRecorder) begin(req *http.Request, redactor *Redactor, trace *traceCollector) *harSession {
+	var session = &harSession{recorder: c, redactor: redactor, trace: trace}
+	var header = redactor.RedactHeader(req.Header)
+	var entry = &harEntry{
+		StartedDateTime: trace.start.Format(time.RFC3339Nano),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         redactor.RedactURL(req.URL.String()),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     session.cookies(req.Cookies(), header.Get("Cookie") != req.Header.Get("Cookie")),
+			Headers:     harHeaders(header),
+			QueryString: make([]harNameValue, 0),
+			HeadersSize: -1,
+			BodySize:    req.ContentLength,
+		},
+	}
+
+	if URL, err := req.URL.Parse(entry.Request.URL); err == nil {
+		for k, values := range URL.Query() {
+			for _, v := range values {
+				entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: k, Value: v})
+			}
+		}
+	}
+
+	session.entry = entry
+	var contentType = req.Header.Get("Content-Type")
+	var snapshot = snapshotBody(req, false)
+	switch {
+	case snapshot.empty:
+	case snapshot.file != "":
+		entry.Request.PostData = &harPostData{MimeType: contentType, Comment: "file " + snapshot.file}
+	case snapshot.stream:
+		session.postData = &harPostData{MimeType: contentType, Comment: "body not sent"}
+		req.Body = internal.Capture(req.Body, max(c.bodyLimit, 0)+1, func(head []byte, size int64, err error) {
+			var postData = session.newPostData(contentType, head, false)
+			session.mu.Lock()
+			session.postData, session.postSize = postData, size
+			session.mu.Unlock()
+		})
+	default:
+		entry.Request.PostData = session.newPostData(contentType, snapshot.data, true)
+		if entry.Request.BodySize <= 0 {
+			entry.Request.BodySize = int64(len(snapshot.data))
+		}
+	}
+	return session
+}
+
+// newPostData 记录请求body的前bodyLimit个字节; complete为false时p只是body的开头
+func (c *harSession) newPostData(contentType string, p []byte, complete bool) *harPostData {
+	var postData = &harPostData{MimeType: contentType}
+	var limit = c.recorder.bodyLimit
+	if complete {
+		p = c.redactor.RedactBody(contentType, p)
+	}
+	if limit > 0 && len(p) > limit {
+		p, postData.Comment = p[:limit], "truncated"
+	}
+	switch {
+	case limit <= 0:
+	case isBinary(p):
+		postData.Text, postData.Comment = "", "binary body omitted"
+	case complete:
+		postData.Text = string(p)
+	default:
+		postData.Text = string(c.redactor.RedactBody(contentType, p))
+	}
+	return postData
+}
+
+func (c *harSession) cookies(cookies []*http.Cookie, masked bool) []harCookie {
+	var list = make([]harCookie, 0, len(cookies))
+	for _, item := range cookies {
+		var cookie = harCookie{
+			Name:     item.Name,
+			Value:    item.Value,
+			Path:     item.Path,
+			Domain:   item.Domain,
+			HTTPOnly: item.HttpOnly,
+			Secure:   item.Secure,
+		}
+		if !item.Expires.IsZero() {
+			cookie.Expires = item.Expires.Format(time.RFC3339)
+		}
+		if masked {
+			cookie.Value = c.redactor.mask()
+		}
+		list = append(list, cookie)
+	}
+	return list
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	var list = make([]harNameValue, 0, len(header))
+	for k, values := range header {
+		for _, v := range values {
+			list = append(list, harNameValue{Name: k, Value: v})
+		}
+	}
+	return list
+}
+
+// finish 记录响应信息或者错误; 未缓存的响应体在读取完毕或者关闭时才写入记录, 不会阻塞流式响应
+func (c *harSession) finish(resp *http.Response, err error) {
+	if c == nil {
+		return
+	}
+	var entry = c.entry
+	entry.Response = harResponse{
+		Cookies:     make([]harCookie, 0),
+		Headers:     make([]harNameValue, 0),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if resp == nil {
+		c.record()
+		return
+	}
+
+	var header = c.redactor.RedactHeader(resp.Header)
+	var masked = header.Get("Set-Cookie") != resp.Header.Get("Set-Cookie")
+	entry.Request.HTTPVersion = resp.Proto
+	entry.Response.Status = resp.StatusCode
+	entry.Response.StatusText = http.StatusText(resp.StatusCode)
+	entry.Response.HTTPVersion = resp.Proto
+	entry.Response.Cookies = c.cookies(resp.Cookies(), masked)
+	entry.Response.Headers = harHeaders(header)
+	entry.Response.RedirectURL = resp.Header.Get("Location")
+	entry.Response.BodySize = resp.ContentLength
+	entry.Response.Content = harBody{Size: resp.ContentLength, MimeType: resp.Header.Get("Content-Type")}
+
+	if resp.Body == nil || resp.Body == http.NoBody {
+		if entry.Response.Content.Size < 0 {
+			entry.Response.Content.Size = 0
+		}
+		c.record()
+		return
+	}
+	if v, ok := resp.Body.(BytesReadCloser); ok {
+		var p = v.Bytes()
+		c.content(p, int64(len(p)))
+		c.record()
+		return
+	}
+	resp.Body = internal.Capture(resp.Body, max(c.recorder.bodyLimit, 0)+1, func(head []byte, size int64, err error) {
+		if err != io.EOF && entry.Response.Content.Size > size {
+			size = entry.Response.Content.Size
+		}
+		c.content(head, size)
+		c.record()
+	})
+}
+
+// record 计算耗时并写入记录, receive为收到首字节到body读取完毕的时间
+func (c *harSession) record() {
+	var entry = c.entry
+	c.mu.Lock()
+	if c.postData != nil {
+		entry.Request.PostData = c.postData
+		if entry.Request.BodySize <= 0 {
+			entry.Request.BodySize = c.postSize
+		}
+	}
+	c.mu.Unlock()
+	var trace = c.trace.snapshot()
+	var now = time.Now()
+
+	if trace.remoteAddr != nil {
+		if host, _, err := net.SplitHostPort(trace.remoteAddr.String()); err == nil {
+			entry.ServerIPAddress = host
+		}
+	}
+	if trace.localAddr != nil {
+		if _, port, err := net.SplitHostPort(trace.localAddr.String()); err == nil {
+			entry.Connection = port
+		}
+	}
+
+	var timings = harTimings{
+		DNS:     milliseconds(between(trace.dnsStart, trace.dnsDone)),
+		Connect: milliseconds(between(trace.connectStart, trace.connectDone)),
+		SSL:     milliseconds(between(trace.tlsStart, trace.tlsDone)),
+		Send:    milliseconds(between(trace.gotConn, trace.wroteRequest)),
+		Wait:    milliseconds(between(trace.wroteRequest, trace.firstByte)),
+		Receive: milliseconds(between(trace.firstByte, now)),
+		Blocked: milliseconds(between(trace.start, trace.getConn)),
+	}
+	// HAR规定connect包含ssl
+	if timings.SSL > 0 {
+		timings.Connect = milliseconds(between(trace.connectStart, trace.tlsDone))
+	}
+	if timings.Send < 0 {
+		timings.Send = 0
+	}
+	if timings.Wait < 0 {
+		timings.Wait = 0
+	}
+	if timings.Receive < 0 {
+		timings.Receive = 0
+	}
+	entry.Timings = timings
+	entry.Time = milliseconds(now.Sub(trace.start))
+	c.recorder.add(entry)
+}
+
+// content 记录body的前bodyLimit个字节, size为body的实际大小
+func (c *harSession) content(p []byte, size int64) {
+	var content = &c.entry.Response.Content
+	content.Size = size
+	if c.recorder.bodyLimit <= 0 {
+		return
+	}
+	if len(p) > c.recorder.bodyLimit {
+		p, content.Comment = p[:c.recorder.bodyLimit], "truncated"
+	}
+	if utf8.Valid(p) {
+		content.Text = string(c.redactor.RedactBody(content.MimeType, p))
+	} else {
+		content.Text, content.Encoding = base64.StdEncoding.EncodeToString(p), "base64"
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	if d < 0 {
+		return -1
+	}
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
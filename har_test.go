@@ -0,0 +1,169 @@
+package hasaki
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHARRecorder(t *testing.T) {
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/binary":
+			writer.WriteHeader(http.StatusOK)
+			writer.Write([]byte{0, 1, 2, 255})
+		default:
+			http.SetCookie(writer, &http.Cookie{Name: "session", Value: "abc", HttpOnly: true})
+			writer.Header().Set("Content-Type", MimeJson)
+			writer.WriteHeader(http.StatusOK)
+			writer.Write([]byte(`{"name":"caster","description":"hello world"}`))
+		}
+	}))
+	go srv.ListenAndServe()
+	time.Sleep(100 * time.Millisecond)
+
+	var buf = bytes.NewBuffer(nil)
+	var recorder = NewHARRecorder(buf).SetBodyLimit(32)
+	var cli, _ = NewClient(WithHARRecorder(recorder))
+
+	resp := cli.Post("http://%s/users?page=1", addr).
+		SetHeader("Cookie", "sid=123").
+		SetHeader("Authorization", "Bearer xxx").
+		Send(Any{"name": "caster"})
+	assert.NoError(t, resp.Err())
+	p, err := resp.ReadBody()
+	assert.NoError(t, err)
+	assert.Equal(t, string(p), `{"name":"caster","description":"hello world"}`)
+
+	resp = cli.Get("http://%s/binary", addr).Send(nil)
+	assert.NoError(t, resp.Err())
+	assert.Equal(t, recorder.Len(), 1)
+	_, err = resp.ReadBody()
+	assert.NoError(t, err)
+	assert.Error(t, cli.Get("http://%s", nextAddr()).Send(nil).Err())
+	assert.Equal(t, recorder.Len(), 3)
+
+	assert.NoError(t, recorder.Flush())
+	assert.Equal(t, recorder.Len(), 0)
+
+	var doc harDocument
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, doc.Log.Version, "1.2")
+	assert.Equal(t, len(doc.Log.Entries), 3)
+
+	var entry = doc.Log.Entries[0]
+	assert.Equal(t, entry.Request.Method, http.MethodPost)
+	assert.Equal(t, entry.Request.QueryString, []harNameValue{{Name: "page", Value: "1"}})
+	assert.Equal(t, entry.Request.Cookies[0].Value, defaultRedactMask)
+	assert.Equal(t, entry.Request.PostData.Text, `{"name":"caster"}`+"\n")
+	assert.False(t, strings.Contains(buf.String(), "Bearer xxx"))
+	assert.Equal(t, entry.Response.Status, http.StatusOK)
+	assert.Equal(t, entry.Response.Cookies[0].Value, defaultRedactMask)
+	assert.True(t, entry.Response.Cookies[0].HTTPOnly)
+	assert.Equal(t, entry.Response.Content.Text, `{"name":"caster","description":"`)
+	assert.Equal(t, entry.Response.Content.Comment, "truncated")
+	assert.Equal(t, entry.ServerIPAddress, "127.0.0.1")
+	assert.GreaterOrEqual(t, entry.Timings.Wait, float64(0))
+	assert.Greater(t, entry.Time, float64(0))
+
+	assert.Equal(t, doc.Log.Entries[1].Response.Content.Encoding, "base64")
+	assert.Equal(t, doc.Log.Entries[1].Response.Content.Text, "AAEC/w==")
+	assert.NotEmpty(t, doc.Log.Entries[2].Error)
+	assert.Equal(t, doc.Log.Entries[2].Response.Status, 0)
+
+	buf.Reset()
+	assert.NoError(t, recorder.Flush())
+	assert.True(t, strings.Contains(buf.String(), `"entries": []`))
+}
+
+func TestHARRecorder_Stream(t *testing.T) {
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/event-stream")
+		writer.Write([]byte("data: hello\n\n"))
+		writer.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+		writer.Write([]byte("data: world\n\n"))
+	})
+	go srv.ListenAndServe()
+	defer srv.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	var buf = bytes.NewBuffer(nil)
+	var recorder = NewHARRecorder(buf)
+	var cli, _ = NewClient(WithHARRecorder(recorder))
+
+	var startTime = time.Now()
+	var resp = cli.Get("http://%s", addr).Send(nil)
+	assert.NoError(t, resp.Err())
+	assert.Less(t, time.Since(startTime), 100*time.Millisecond)
+	assert.Equal(t, recorder.Len(), 0)
+
+	p, err := resp.ReadBody()
+	assert.NoError(t, err)
+	assert.Equal(t, string(p), "data: hello\n\ndata: world\n\n")
+	assert.Equal(t, recorder.Len(), 1)
+
+	assert.NoError(t, recorder.Flush())
+	var doc harDocument
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	var entry = doc.Log.Entries[0]
+	assert.Equal(t, entry.Response.Content.Text, "data: hello\n\ndata: world\n\n")
+	assert.Equal(t, entry.Response.Content.Size, int64(26))
+	assert.GreaterOrEqual(t, entry.Timings.Receive, float64(200))
+}
+
+func TestHARRecorder_StreamRequest(t *testing.T) {
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		p, _ := io.ReadAll(request.Body)
+		writer.Write([]byte(strconv.Itoa(len(p))))
+	})
+	go srv.ListenAndServe()
+	defer srv.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	var buf = bytes.NewBuffer(nil)
+	var recorder = NewHARRecorder(buf).SetBodyLimit(16)
+	var cli, _ = NewClient(WithHARRecorder(recorder))
+
+	// 流式body由Transport读取, HAR只保留开头
+	var text = strings.Repeat("hasaki", 100000)
+	var reader = &countingReader{Reader: strings.NewReader(text)}
+	var resp = cli.Post("http://%s", addr).SetEncoder(NewStreamEncoder(MimeStream)).Send(reader)
+	assert.NoError(t, resp.Err())
+	p, err := resp.ReadBody()
+	assert.NoError(t, err)
+	assert.Equal(t, string(p), strconv.Itoa(len(text)))
+	assert.Equal(t, reader.n, len(text))
+
+	assert.NoError(t, recorder.Flush())
+	var doc harDocument
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	var entry = doc.Log.Entries[0]
+	assert.Equal(t, entry.Request.PostData.Text, text[:16])
+	assert.Equal(t, entry.Request.PostData.Comment, "truncated")
+	assert.Equal(t, entry.Request.BodySize, int64(len(text)))
+}
+
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += n
+	return n, err
+}
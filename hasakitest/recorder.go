@@ -0,0 +1,419 @@
+package hasakitest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/lxzan/hasaki"
+	"github.com/pkg/errors"
+)
+
+// ErrInteractionNotFound 回放模式下磁带中没有匹配的记录
+// No recorded interaction matches the request in replay mode
+var ErrInteractionNotFound = errors.New("hasakitest: interaction not found")
+
+var errCodecRequired = errors.New("hasakitest: yaml cassette requires WithCassetteCodec")
+
+// Mode 录制器的工作模式
+// Working mode of the recorder
+type Mode uint8
+
+const (
+	ModeReplayOrRecord Mode = iota // 优先回放, 没有匹配的记录时发出真实请求并录制
+	ModeRecord                     // 总是发出真实请求, 覆盖已有的磁带
+	ModeReplay                     // 只回放, 没有匹配的记录时返回ErrInteractionNotFound
+	ModePassthrough                // 直接发出真实请求, 不读写磁带
+)
+
+const cassetteVersion = 1
+
+type (
+	config struct {
+		mode      Mode
+		transport http.RoundTripper
+		redactor  *hasaki.Redactor
+		matchBody bool
+		headers   []string
+		matcher   func(actual, recorded *RecordedRequest) bool
+		codec     hasaki.Codec
+	}
+
+	Option func(c *config)
+)
+
+// WithMode 设置工作模式, 默认为ModeReplayOrRecord
+// Setting the working mode, default is ModeReplayOrRecord
+func WithMode(mode Mode) Option {
+	return func(c *config) {
+		c.mode = mode
+	}
+}
+
+// WithTransport 设置发出真实请求的Transport, 默认为http.DefaultTransport
+// Setting the transport for real requests, default is http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *config) {
+		c.transport = transport
+	}
+}
+
+// WithRedactor 设置保存前的脱敏规则, 默认为hasaki.DefaultRedactor.
+// 匹配时请求同样会先脱敏, 因此被脱敏的字段不参与比较.
+// Setting the redaction rules applied before saving, default is hasaki.DefaultRedactor.
+// Requests are redacted before matching as well, so redacted fields are not compared.
+func WithRedactor(redactor *hasaki.Redactor) Option {
+	return func(c *config) {
+		c.redactor = redactor
+	}
+}
+
+// WithMatchBody 匹配时比较请求体, JSON按语义比较
+// Comparing the request body when matching, JSON is compared semantically
+func WithMatchBody() Option {
+	return func(c *config) {
+		c.matchBody = true
+	}
+}
+
+// WithMatchHeaders 匹配时比较指定的请求头
+// Comparing the given request headers when matching
+func WithMatchHeaders(keys ...string) Option {
+	return func(c *config) {
+		c.headers = append(c.headers, keys...)
+	}
+}
+
+// WithMatcher 使用自定义函数匹配请求, 覆盖默认的方法/URL/请求体/请求头规则
+// Matching requests with a custom function, replacing the default method/URL/body/header rules
+func WithMatcher(fn func(actual, recorded *RecordedRequest) bool) Option {
+	return func(c *config) {
+		c.matcher = fn
+	}
+}
+
+type (
+	cassette struct {
+		Version      int            `json:"version" yaml:"version"`
+		Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+	}
+
+	// Interaction 一次录制的请求和响应
+	// A recorded request and its response
+	Interaction struct {
+		Request  RecordedRequest  `json:"request" yaml:"request"`
+		Response RecordedResponse `json:"response" yaml:"response"`
+	}
+
+	// RecordedRequest 录制的请求, 已脱敏
+	// A recorded request, already redacted
+	RecordedRequest struct {
+		Method   string      `json:"method" yaml:"method"`
+		URL      string      `json:"url" yaml:"url"`
+		Header   http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+		Body     string      `json:"body,omitempty" yaml:"body,omitempty"`
+		Encoding string      `json:"encoding,omitempty" yaml:"encoding,omitempty"` // body为base64编码时为base64
+	}
+
+	// RecordedResponse 录制的响应, 已脱敏
+	// A recorded response, already redacted
+	RecordedResponse struct {
+		StatusCode int         `json:"status_code" yaml:"status_code"`
+		Header     http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+		Body       string      `json:"body,omitempty" yaml:"body,omitempty"`
+		Encoding   string      `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+	}
+)
+
+// WithCassetteCodec 设置磁带文件的编解码器, 默认为JSON. YAML磁带需要传入contrib/yaml中的yaml.Codec
+// Setting the codec of the cassette file, default is JSON. YAML cassettes need yaml.Codec from contrib/yaml
+func WithCassetteCodec(codec hasaki.Codec) Option {
+	return func(c *config) {
+		c.codec = codec
+	}
+}
+
+// Recorder 录制和回放HTTP交互的Transport, 磁带默认使用JSON格式, 可以通过WithCassetteCodec修改
+// A transport that records and replays HTTP interactions. Cassettes are JSON by default, which can be changed with WithCassetteCodec.
+type Recorder struct {
+	mu       sync.Mutex
+	conf     *config
+	filename string
+	cassette *cassette
+	used     []bool
+	dirty    bool
+}
+
+// NewRecorder 创建录制器并加载已有的磁带. 回放模式下磁带必须存在.
+// Creating a recorder and loading the existing cassette. The cassette must exist in replay mode.
+func NewRecorder(filename string, options ...Option) (*Recorder, error) {
+	var conf = &config{
+		mode:      ModeReplayOrRecord,
+		transport: http.DefaultTransport,
+		redactor:  hasaki.DefaultRedactor,
+	}
+	for _, f := range options {
+		f(conf)
+	}
+
+	// 扩展名为.yaml/.yml却没有设置编解码器时, 提前报错而不是写出JSON
+	var ext = strings.ToLower(filepath.Ext(filename))
+	if conf.codec == nil && (ext == ".yaml" || ext == ".yml") {
+		return nil, errors.Wrap(errCodecRequired, filename)
+	}
+
+	var c = &Recorder{conf: conf, filename: filename, cassette: &cassette{Version: cassetteVersion}}
+	if conf.mode == ModeRecord || conf.mode == ModePassthrough {
+		return c, nil
+	}
+
+	p, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) && conf.mode == ModeReplayOrRecord {
+			return c, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	if conf.codec != nil {
+		err = conf.codec.Decode(bytes.NewReader(p), c.cassette)
+	} else {
+		err = json.Unmarshal(p, c.cassette)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c.used = make([]bool, len(c.cassette.Interactions))
+	return c, nil
+}
+
+// HTTPClient 返回使用该录制器的http.Client, 可以传给hasaki.WithHTTPClient
+// Returns an http.Client using the recorder, which can be passed to hasaki.WithHTTPClient
+func (c *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// NewClient 创建使用该录制器的客户端
+// Creating a client which uses the recorder
+func (c *Recorder) NewClient(options ...hasaki.Option) (*hasaki.Client, error) {
+	return hasaki.NewClient(append([]hasaki.Option{hasaki.WithHTTPClient(c.HTTPClient())}, options...)...)
+}
+
+// Len 返回磁带中的记录数量
+// Returns the number of interactions in the cassette
+func (c *Recorder) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.cassette.Interactions)
+}
+
+// Save 将新录制的交互写入磁带, 没有变化时不写文件
+// Writing newly recorded interactions to the cassette, the file is untouched when nothing changed
+func (c *Recorder) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+
+	p, err := c.encode()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(c.filename), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	if err = os.WriteFile(c.filename, p, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	c.dirty = false
+	return nil
+}
+
+func (c *Recorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	if c.conf.mode == ModePassthrough {
+		return c.conf.transport.RoundTrip(r)
+	}
+
+	body, err := readBody(&r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var actual = c.recordRequest(r, body)
+
+	if c.conf.mode != ModeRecord {
+		if interaction := c.find(actual); interaction != nil {
+			return interaction.Response.response(r)
+		}
+		if c.conf.mode == ModeReplay {
+			return nil, errors.Wrap(ErrInteractionNotFound, r.Method+" "+actual.URL)
+		}
+	}
+
+	resp, err := c.conf.transport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.cassette.Interactions = append(c.cassette.Interactions, &Interaction{
+		Request:  *actual,
+		Response: c.recordResponse(resp, respBody),
+	})
+	c.used = append(c.used, true)
+	c.dirty = true
+	c.mu.Unlock()
+	return resp, nil
+}
+
+// find 按录制顺序查找匹配的记录, 优先使用未回放过的记录
+func (c *Recorder) find(actual *RecordedRequest) *Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var index = -1
+	for i, item := range c.cassette.Interactions {
+		if !c.match(actual, &item.Request) {
+			continue
+		}
+		if !c.used[i] {
+			index = i
+			break
+		}
+		if index < 0 {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+	c.used[index] = true
+	return c.cassette.Interactions[index]
+}
+
+func (c *Recorder) match(actual, recorded *RecordedRequest) bool {
+	if c.conf.matcher != nil {
+		return c.conf.matcher(actual, recorded)
+	}
+	if actual.Method != recorded.Method || actual.URL != recorded.URL {
+		return false
+	}
+	for _, k := range c.conf.headers {
+		if strings.Join(actual.Header.Values(k), ",") != strings.Join(recorded.Header.Values(k), ",") {
+			return false
+		}
+	}
+	return !c.conf.matchBody || bodyEqual(actual.decodeBody(), recorded.decodeBody())
+}
+
+func (c *Recorder) encode() ([]byte, error) {
+	if c.conf.codec == nil {
+		return json.MarshalIndent(c.cassette, "", "  ")
+	}
+	r, err := c.conf.codec.Encode(c.cassette)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return io.ReadAll(r)
+}
+
+func (c *Recorder) recordRequest(r *http.Request, body []byte) *RecordedRequest {
+	var redactor = c.conf.redactor
+	var request = &RecordedRequest{
+		Method: r.Method,
+		URL:    redactor.RedactURL(r.URL.String()),
+		Header: redactor.RedactHeader(r.Header),
+	}
+	request.Body, request.Encoding = encodeBody(redactor.RedactBody(r.Header.Get("Content-Type"), body))
+	return request
+}
+
+func (c *Recorder) recordResponse(resp *http.Response, body []byte) RecordedResponse {
+	var redactor = c.conf.redactor
+	var response = RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     redactor.RedactHeader(resp.Header),
+	}
+	response.Body, response.Encoding = encodeBody(redactor.RedactBody(resp.Header.Get("Content-Type"), body))
+	return response
+}
+
+func (c *RecordedRequest) decodeBody() []byte {
+	return decodeBody(c.Body, c.Encoding)
+}
+
+func (c *RecordedResponse) response(r *http.Request) (*http.Response, error) {
+	var body = decodeBody(c.Body, c.Encoding)
+	var header = c.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	// 脱敏可能改变body长度
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        strconv.Itoa(c.StatusCode) + " " + http.StatusText(c.StatusCode),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}, nil
+}
+
+// readBody 读取body并替换为可重复读取的副本
+func readBody(rc *io.ReadCloser) ([]byte, error) {
+	if *rc == nil || *rc == http.NoBody {
+		return nil, nil
+	}
+	p, err := io.ReadAll(*rc)
+	_ = (*rc).Close()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	*rc = io.NopCloser(bytes.NewReader(p))
+	return p, nil
+}
+
+func encodeBody(p []byte) (body string, encoding string) {
+	if utf8.Valid(p) {
+		return string(p), ""
+	}
+	return base64.StdEncoding.EncodeToString(p), "base64"
+}
+
+func decodeBody(body string, encoding string) []byte {
+	if encoding == "base64" {
+		p, _ := base64.StdEncoding.DecodeString(body)
+		return p
+	}
+	return []byte(body)
+}
+
+// bodyEqual 比较两个body, 都是JSON时按语义比较
+func bodyEqual(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,125 @@
+package hasakitest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lxzan/hasaki"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	var hits int64
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt64(&hits, 1)
+		switch request.URL.Path {
+		case "/binary":
+			writer.Write([]byte{0, 1, 2, 255})
+		default:
+			http.SetCookie(writer, &http.Cookie{Name: "session", Value: "abc"})
+			writer.Header().Set("Content-Type", hasaki.MimeJson)
+			writer.Write([]byte(`{"token":"secret","name":"` + request.URL.Query().Get("name") + `"}`))
+		}
+	}))
+	defer srv.Close()
+
+	var redactor = &hasaki.Redactor{
+		Headers:    []string{"Authorization", "Set-Cookie"},
+		QueryKeys:  []string{"access_token"},
+		JSONFields: []string{"token", "password"},
+	}
+
+	for name, codec := range map[string]hasaki.Codec{"cassette.json": nil, "cassette.txt": hasaki.JsonCodec} {
+		t.Run(name, func(t *testing.T) {
+			atomic.StoreInt64(&hits, 0)
+			var filename = filepath.Join(t.TempDir(), "fixtures", name)
+
+			// 录制
+			rec, err := NewRecorder(filename, WithRedactor(redactor), WithMatchBody(), WithCassetteCodec(codec))
+			assert.NoError(t, err)
+			cli, _ := rec.NewClient()
+			p, err := cli.Post(srv.URL+"/users?name=caster&access_token=xxx").
+				SetHeader("Authorization", "Bearer xxx").
+				Send(hasaki.Any{"name": "caster", "password": "123"}).
+				ReadBody()
+			assert.NoError(t, err)
+			assert.Equal(t, string(p), `{"token":"secret","name":"caster"}`)
+			_, err = cli.Get(srv.URL + "/binary").Send(nil).ReadBody()
+			assert.NoError(t, err)
+			assert.Equal(t, rec.Len(), 2)
+			assert.NoError(t, rec.Save())
+
+			content, err := os.ReadFile(filename)
+			assert.NoError(t, err)
+			assert.False(t, strings.Contains(string(content), "Bearer xxx"))
+			assert.False(t, strings.Contains(string(content), "secret"))
+			assert.False(t, strings.Contains(string(content), "session=abc"))
+			assert.False(t, strings.Contains(string(content), "access_token=xxx"))
+
+			// 回放
+			rec, err = NewRecorder(filename, WithMode(ModeReplay), WithRedactor(redactor), WithMatchBody(), WithCassetteCodec(codec))
+			assert.NoError(t, err)
+			assert.Equal(t, rec.Len(), 2)
+			cli, _ = rec.NewClient()
+			var result = hasaki.Any{}
+			var resp = cli.Post(srv.URL + "/users?name=caster&access_token=yyy").
+				Send(hasaki.Any{"password": "456", "name": "caster"})
+			assert.NoError(t, resp.BindJSON(&result))
+			assert.Equal(t, result, hasaki.Any{"token": "******", "name": "caster"})
+			p, err = cli.Get(srv.URL + "/binary").Send(nil).ReadBody()
+			assert.NoError(t, err)
+			assert.Equal(t, p, []byte{0, 1, 2, 255})
+			assert.Equal(t, atomic.LoadInt64(&hits), int64(2))
+
+			err = cli.Post(srv.URL + "/users?name=caster").Send(hasaki.Any{"name": "other"}).Err()
+			assert.True(t, errors.Is(err, ErrInteractionNotFound))
+		})
+	}
+
+	t.Run("replay or record", func(t *testing.T) {
+		atomic.StoreInt64(&hits, 0)
+		var filename = filepath.Join(t.TempDir(), "cassette.json")
+		rec, err := NewRecorder(filename, WithMatchHeaders("X-Tenant"))
+		assert.NoError(t, err)
+		cli, _ := rec.NewClient()
+		for i := 0; i < 3; i++ {
+			_, err = cli.Get(srv.URL+"/users").SetHeader("X-Tenant", "a").Send(nil).ReadBody()
+			assert.NoError(t, err)
+		}
+		_, err = cli.Get(srv.URL+"/users").SetHeader("X-Tenant", "b").Send(nil).ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, atomic.LoadInt64(&hits), int64(2))
+		assert.Equal(t, rec.Len(), 2)
+		assert.NoError(t, rec.Save())
+	})
+
+	t.Run("passthrough", func(t *testing.T) {
+		atomic.StoreInt64(&hits, 0)
+		var filename = filepath.Join(t.TempDir(), "cassette.json")
+		rec, err := NewRecorder(filename, WithMode(ModePassthrough))
+		assert.NoError(t, err)
+		cli, _ := rec.NewClient()
+		assert.NoError(t, cli.Get(srv.URL).Send(nil).Err())
+		assert.Equal(t, atomic.LoadInt64(&hits), int64(1))
+		assert.Equal(t, rec.Len(), 0)
+		assert.NoError(t, rec.Save())
+		_, err = os.Stat(filename)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("missing cassette", func(t *testing.T) {
+		_, err := NewRecorder(filepath.Join(t.TempDir(), "cassette.json"), WithMode(ModeReplay))
+		assert.Error(t, err)
+	})
+
+	t.Run("yaml without codec", func(t *testing.T) {
+		_, err := NewRecorder(filepath.Join(t.TempDir(), "cassette.yaml"))
+		assert.True(t, errors.Is(err, errCodecRequired))
+	})
+}
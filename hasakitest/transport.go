@@ -0,0 +1,358 @@
+package hasakitest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lxzan/hasaki"
+	"github.com/pkg/errors"
+)
+
+// ErrUnexpectedRequest 没有匹配的预期
+// No expectation matches the request
+var ErrUnexpectedRequest = errors.New("hasakitest: unexpected request")
+
+// TestingT 是*testing.T的子集
+// A subset of *testing.T
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Transport 模拟的http.RoundTripper, 按注册顺序匹配预期并返回预设的响应
+// A mock http.RoundTripper which matches expectations in registration order and returns canned responses
+type Transport struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []string
+}
+
+// NewTransport 创建模拟的Transport
+// Creating a mock transport
+func NewTransport() *Transport {
+	return &Transport{}
+}
+
+// On 注册一个预期. method为空或者*时匹配任意方法.
+// pattern以/开头时匹配URL路径, 否则匹配不含查询参数的完整URL, 语法同path.Match.
+// Registering an expectation. An empty method or * matches any method.
+// A pattern starting with / matches the URL path, otherwise the full URL without query, using path.Match syntax.
+func (c *Transport) On(method string, pattern string) *Expectation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var e = &Expectation{
+		owner:   c,
+		method:  strings.ToUpper(method),
+		pattern: pattern,
+		status:  http.StatusOK,
+		header:  http.Header{},
+	}
+	c.expectations = append(c.expectations, e)
+	return e
+}
+
+// HTTPClient 返回使用该Transport的http.Client, 可以传给hasaki.WithHTTPClient
+// Returns an http.Client using the transport, which can be passed to hasaki.WithHTTPClient
+func (c *Transport) HTTPClient() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// NewClient 创建使用该Transport的客户端
+// Creating a client which uses the transport
+func (c *Transport) NewClient(options ...hasaki.Option) (*hasaki.Client, error) {
+	return hasaki.NewClient(append([]hasaki.Option{hasaki.WithHTTPClient(c.HTTPClient())}, options...)...)
+}
+
+func (c *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		p, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		body = p
+	}
+
+	c.mu.Lock()
+	var matched *Expectation
+	for _, item := range c.expectations {
+		if item.match(r, body) {
+			matched = item
+			break
+		}
+	}
+	if matched == nil {
+		var desc = r.Method + " " + r.URL.String()
+		c.unexpected = append(c.unexpected, desc)
+		c.mu.Unlock()
+		return nil, errors.Wrap(ErrUnexpectedRequest, desc)
+	}
+	matched.calls++
+	c.mu.Unlock()
+
+	if matched.delay > 0 {
+		var timer = time.NewTimer(matched.delay)
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			return nil, r.Context().Err()
+		}
+	}
+	return matched.response(r)
+}
+
+// AssertExpectations 断言所有预期都被满足并且没有意外的请求
+// Asserting that all expectations were met and no unexpected request was made
+func (c *Transport) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var ok = true
+	for _, item := range c.expectations {
+		switch {
+		case item.times > 0 && item.calls != item.times:
+			t.Errorf("hasakitest: expected %s to be called %d times, got %d", item, item.times, item.calls)
+			ok = false
+		case item.times == 0 && item.calls == 0 && !item.optional:
+			t.Errorf("hasakitest: expected %s to be called", item)
+			ok = false
+		}
+	}
+	for _, item := range c.unexpected {
+		t.Errorf("hasakitest: unexpected request %s", item)
+		ok = false
+	}
+	return ok
+}
+
+// Reset 清空所有预期和调用记录
+// Clearing all expectations and calls
+func (c *Transport) Reset() {
+	c.mu.Lock()
+	c.expectations, c.unexpected = nil, nil
+	c.mu.Unlock()
+}
+
+// Expectation 请求的预期和预设的响应
+// The expectation of a request and its canned response
+type Expectation struct {
+	owner    *Transport
+	method   string
+	pattern  string
+	headers  []pair
+	queries  []pair
+	matchers []func(body []byte) bool
+
+	status int
+	header http.Header
+	body   []byte
+	err    error
+	delay  time.Duration
+
+	times    int
+	optional bool
+	calls    int
+}
+
+type pair struct{ key, value string }
+
+// WithHeader 要求请求头包含key=value
+// Requiring the request header key to be value
+func (c *Expectation) WithHeader(key, value string) *Expectation {
+	c.headers = append(c.headers, pair{key: key, value: value})
+	return c
+}
+
+// WithQuery 要求查询参数包含key=value
+// Requiring the query parameter key to be value
+func (c *Expectation) WithQuery(key, value string) *Expectation {
+	c.queries = append(c.queries, pair{key: key, value: value})
+	return c
+}
+
+// WithJSONBody 要求请求体与v序列化后的JSON等价, 忽略字段顺序和空白
+// Requiring the request body to be JSON equivalent to v, ignoring field order and whitespace
+func (c *Expectation) WithJSONBody(v any) *Expectation {
+	var expected, err = normalizeJSON(v)
+	return c.WithBodyMatcher(func(body []byte) bool {
+		var actual any
+		return err == nil && jsoniter.Unmarshal(body, &actual) == nil && reflect.DeepEqual(actual, expected)
+	})
+}
+
+// WithBodyMatcher 使用自定义函数匹配请求体
+// Matching the request body with a custom function
+func (c *Expectation) WithBodyMatcher(fn func(body []byte) bool) *Expectation {
+	c.matchers = append(c.matchers, fn)
+	return c
+}
+
+// Reply 设置响应状态码
+// Setting the response status code
+func (c *Expectation) Reply(status int) *Expectation {
+	c.status = status
+	return c
+}
+
+// ReplyHeader 设置响应头
+// Setting a response header
+func (c *Expectation) ReplyHeader(key, value string) *Expectation {
+	c.header.Set(key, value)
+	return c
+}
+
+// ReplyBody 设置响应体
+// Setting the response body
+func (c *Expectation) ReplyBody(body []byte) *Expectation {
+	c.body = body
+	return c
+}
+
+// ReplyString 设置字符串响应体
+// Setting a string response body
+func (c *Expectation) ReplyString(body string) *Expectation {
+	return c.ReplyBody([]byte(body))
+}
+
+// ReplyJSON 设置JSON响应体
+// Setting a JSON response body
+func (c *Expectation) ReplyJSON(v any) *Expectation {
+	p, err := jsoniter.Marshal(v)
+	if err != nil {
+		c.err = errors.WithStack(err)
+		return c
+	}
+	c.header.Set("Content-Type", hasaki.MimeJson)
+	return c.ReplyBody(p)
+}
+
+// ReplyError 返回错误而不是响应
+// Returning an error instead of a response
+func (c *Expectation) ReplyError(err error) *Expectation {
+	c.err = err
+	return c
+}
+
+// Delay 延迟返回响应, 请求的context取消时提前返回
+// Delaying the response, returning early when the request context is done
+func (c *Expectation) Delay(d time.Duration) *Expectation {
+	c.delay = d
+	return c
+}
+
+// Times 预期被调用n次, 达到次数后不再匹配
+// Expecting to be called n times, the expectation no longer matches after that
+func (c *Expectation) Times(n int) *Expectation {
+	c.times = n
+	return c
+}
+
+// Once 等价于Times(1)
+// Same as Times(1)
+func (c *Expectation) Once() *Expectation {
+	return c.Times(1)
+}
+
+// Maybe 允许不被调用
+// Allowing the expectation to be never called
+func (c *Expectation) Maybe() *Expectation {
+	c.optional = true
+	return c
+}
+
+// Calls 返回被调用的次数
+// Returns the number of calls
+func (c *Expectation) Calls() int {
+	c.owner.mu.Lock()
+	defer c.owner.mu.Unlock()
+	return c.calls
+}
+
+func (c *Expectation) String() string {
+	var method = c.method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + c.pattern
+}
+
+func (c *Expectation) match(r *http.Request, body []byte) bool {
+	if c.times > 0 && c.calls >= c.times {
+		return false
+	}
+	if c.method != "" && c.method != "*" && c.method != r.Method {
+		return false
+	}
+
+	var target = r.URL.Path
+	if !strings.HasPrefix(c.pattern, "/") {
+		var u = *r.URL
+		u.RawQuery, u.Fragment = "", ""
+		target = u.String()
+	}
+	if ok, _ := path.Match(c.pattern, target); !ok {
+		return false
+	}
+
+	for _, item := range c.headers {
+		if r.Header.Get(item.key) != item.value {
+			return false
+		}
+	}
+	var query = r.URL.Query()
+	for _, item := range c.queries {
+		if query.Get(item.key) != item.value {
+			return false
+		}
+	}
+	for _, fn := range c.matchers {
+		if !fn(body) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Expectation) response(r *http.Request) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	var header = c.header.Clone()
+	if header.Get("Content-Length") == "" {
+		header.Set("Content-Length", strconv.Itoa(len(c.body)))
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.status, http.StatusText(c.status)),
+		StatusCode:    c.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       r,
+	}, nil
+}
+
+// normalizeJSON 将v序列化再反序列化, 以便与请求体比较
+func normalizeJSON(v any) (any, error) {
+	p, err := jsoniter.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var result any
+	err = jsoniter.Unmarshal(p, &result)
+	return result, err
+}
@@ -0,0 +1,100 @@
+package hasakitest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lxzan/hasaki"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	messages []string
+}
+
+func (c *recorder) Helper() {}
+
+func (c *recorder) Errorf(format string, args ...any) {
+	c.messages = append(c.messages, fmt.Sprintf(format, args...))
+}
+
+func TestTransport(t *testing.T) {
+	t.Run("match", func(t *testing.T) {
+		var mock = NewTransport()
+		var cli, _ = mock.NewClient()
+		mock.On(http.MethodPost, "/users/*").
+			WithHeader("X-Token", "123").
+			WithQuery("page", "1").
+			WithJSONBody(hasaki.Any{"name": "caster", "age": 1}).
+			Reply(http.StatusCreated).
+			ReplyJSON(hasaki.Any{"id": 1}).
+			Once()
+		var fallback = mock.On("", "http://api.example.com/users/*").ReplyString("fallback")
+
+		var result = struct {
+			Id int `json:"id"`
+		}{}
+		var resp = cli.Post("http://api.example.com/users/1").
+			SetHeader("X-Token", "123").
+			SetQuery("page=1").
+			Send(map[string]any{"age": 1, "name": "caster"})
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, resp.StatusCode, http.StatusCreated)
+		assert.NoError(t, resp.BindJSON(&result))
+		assert.Equal(t, result.Id, 1)
+
+		// 次数用完后匹配下一个预期
+		p, err := cli.Post("http://api.example.com/users/1").
+			SetHeader("X-Token", "123").
+			SetQuery("page=1").
+			Send(hasaki.Any{"age": 1, "name": "caster"}).
+			ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), "fallback")
+		assert.Equal(t, fallback.Calls(), 1)
+		assert.True(t, mock.AssertExpectations(t))
+	})
+
+	t.Run("unexpected", func(t *testing.T) {
+		var mock = NewTransport()
+		var cli, _ = mock.NewClient()
+		mock.On(http.MethodGet, "/a").Times(2)
+		mock.On(http.MethodGet, "/b")
+		mock.On(http.MethodGet, "/c").Maybe()
+
+		assert.NoError(t, cli.Get("http://localhost/a").Send(nil).Err())
+		var err = cli.Delete("http://localhost/a").Send(nil).Err()
+		assert.True(t, errors.Is(err, ErrUnexpectedRequest))
+
+		var r = &recorder{}
+		assert.False(t, mock.AssertExpectations(r))
+		assert.Equal(t, r.messages, []string{
+			"hasakitest: expected GET /a to be called 2 times, got 1",
+			"hasakitest: expected GET /b to be called",
+			"hasakitest: unexpected request DELETE http://localhost/a",
+		})
+
+		mock.Reset()
+		assert.True(t, mock.AssertExpectations(t))
+	})
+
+	t.Run("error and delay", func(t *testing.T) {
+		var mock = NewTransport()
+		var cli, _ = mock.NewClient()
+		var expectedErr = errors.New("connection refused")
+		mock.On(http.MethodGet, "/error").ReplyError(expectedErr)
+		mock.On(http.MethodGet, "/slow").Delay(time.Second)
+
+		assert.True(t, errors.Is(cli.Get("http://localhost/error").Send(nil).Err(), expectedErr))
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		var err = cli.Get("http://localhost/slow").SetContext(ctx).Send(nil).Err()
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.True(t, mock.AssertExpectations(t))
+	})
+}
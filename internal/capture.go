@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// CaptureReadCloser 保存读取过的前若干字节并统计总字节数, 读取完毕, 出错或者关闭时回调一次
+// Keep the first bytes read and count the total, invoking the callback once on EOF, error or close
+type CaptureReadCloser struct {
+	io.ReadCloser
+	limit int
+	head  []byte
+	size  int64
+	err   error
+	once  sync.Once
+	done  func(head []byte, size int64, err error)
+}
+
+// Capture 包装rc, 最多保存limit个字节; 不会主动读取rc, 适用于流式响应.
+// 回调的err在读取完毕时为io.EOF, 读取出错时为该错误, 提前关闭时为nil
+// Wrap rc and keep at most limit bytes; rc is never read ahead, so it works with streaming responses.
+// The callback receives io.EOF when rc is fully read, the read error if reading fails, or nil if closed early
+func Capture(rc io.ReadCloser, limit int, done func(head []byte, size int64, err error)) *CaptureReadCloser {
+	return &CaptureReadCloser{ReadCloser: rc, limit: limit, done: done}
+}
+
+// Track 在rc读取完毕, 出错或者关闭时回调一次, 读取完毕和关闭时err为nil; rc为空时立即回调
+// Invoke done once when rc is fully read, fails or is closed, err is nil on EOF and close; done is invoked immediately if rc is empty
+func Track(rc io.ReadCloser, done func(size int64, err error)) io.ReadCloser {
+	if rc == nil || rc == http.NoBody {
+		done(0, nil)
+		return rc
+	}
+	return Capture(rc, 0, func(head []byte, size int64, err error) {
+		if err == io.EOF {
+			err = nil
+		}
+		done(size, err)
+	})
+}
+
+func (c *CaptureReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if room := c.limit - len(c.head); room > 0 && n > 0 {
+		c.head = append(c.head, p[:min(n, room)]...)
+	}
+	c.size += int64(n)
+	if err != nil {
+		c.err = err
+		c.finish()
+	}
+	return n, err
+}
+
+func (c *CaptureReadCloser) Close() error {
+	c.finish()
+	return c.ReadCloser.Close()
+}
+
+func (c *CaptureReadCloser) finish() {
+	c.once.Do(func() { c.done(c.head, c.size, c.err) })
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapture(t *testing.T) {
+	t.Run("eof", func(t *testing.T) {
+		var calls = 0
+		var rc = Capture(io.NopCloser(strings.NewReader("hello world")), 5, func(head []byte, size int64, err error) {
+			calls++
+			assert.Equal(t, string(head), "hello")
+			assert.Equal(t, size, int64(11))
+			assert.Equal(t, err, io.EOF)
+		})
+		all, err := io.ReadAll(rc)
+		assert.NoError(t, err)
+		assert.Equal(t, string(all), "hello world")
+		assert.NoError(t, rc.Close())
+		assert.Equal(t, calls, 1)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		var calls = 0
+		var rc = Capture(io.NopCloser(strings.NewReader("hello world")), 16, func(head []byte, size int64, err error) {
+			calls++
+			assert.Equal(t, string(head), "hel")
+			assert.Equal(t, size, int64(3))
+			assert.NoError(t, err)
+		})
+		_, _ = rc.Read(make([]byte, 3))
+		assert.Equal(t, calls, 0)
+		assert.NoError(t, rc.Close())
+		assert.Equal(t, calls, 1)
+	})
+}
+
+func TestTrack(t *testing.T) {
+	var calls = 0
+	var rc = Track(io.NopCloser(strings.NewReader("hello")), func(size int64, err error) {
+		calls++
+		assert.Equal(t, size, int64(5))
+		assert.NoError(t, err)
+	})
+	_, _ = io.ReadAll(rc)
+	assert.NoError(t, rc.Close())
+	assert.Equal(t, calls, 1)
+
+	var readErr = errors.New("read error")
+	rc = Track(io.NopCloser(iotest.ErrReader(readErr)), func(size int64, err error) {
+		calls++
+		assert.Equal(t, err, readErr)
+	})
+	_, _ = io.ReadAll(rc)
+	assert.Equal(t, calls, 2)
+
+	assert.Equal(t, Track(http.NoBody, func(size int64, err error) { calls++ }), http.NoBody)
+	assert.Equal(t, calls, 3)
+}
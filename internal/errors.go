@@ -0,0 +1,27 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// ErrorKind 将请求错误归类为canceled, timeout, dns, connect或者other, 用作低基数的指标标签
+// Classify a request error as canceled, timeout, dns, connect or other, suitable as a low cardinality metric label
+func ErrorKind(err error) string {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return "connect"
+	default:
+		return "other"
+	}
+}
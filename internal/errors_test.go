@@ -0,0 +1,18 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKind(t *testing.T) {
+	assert.Equal(t, ErrorKind(context.Canceled), "canceled")
+	assert.Equal(t, ErrorKind(context.DeadlineExceeded), "timeout")
+	assert.Equal(t, ErrorKind(&net.DNSError{Err: "no such host"}), "dns")
+	assert.Equal(t, ErrorKind(&net.OpError{Op: "dial", Err: errors.New("connection refused")}), "connect")
+	assert.Equal(t, ErrorKind(errors.New("unknown")), "other")
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+)
+
+// ReadCloser 组合Reader和Closer
+// Combine a Reader and a Closer
+type ReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Peek 读取rc的前n个字节, 并返回一个可以从头读取完整内容的ReadCloser
+// Read the first n bytes of rc and return a ReadCloser that still yields the full content
+func Peek(rc io.ReadCloser, n int) ([]byte, io.ReadCloser, error) {
+	if rc == nil || n <= 0 {
+		return nil, rc, nil
+	}
+	var p = make([]byte, n)
+	num, err := io.ReadFull(rc, p)
+	p = p[:num]
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	var r = &ReadCloser{Reader: io.MultiReader(bytes.NewReader(p), rc), Closer: rc}
+	return p, r, err
+}
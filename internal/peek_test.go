@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeek(t *testing.T) {
+	t.Run("partial", func(t *testing.T) {
+		p, rc, err := Peek(io.NopCloser(strings.NewReader("hello world")), 5)
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), "hello")
+		all, _ := io.ReadAll(rc)
+		assert.Equal(t, string(all), "hello world")
+		assert.NoError(t, rc.Close())
+	})
+
+	t.Run("short", func(t *testing.T) {
+		p, rc, err := Peek(io.NopCloser(strings.NewReader("hi")), 5)
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), "hi")
+		all, _ := io.ReadAll(rc)
+		assert.Equal(t, string(all), "hi")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		p, rc, err := Peek(nil, 5)
+		assert.NoError(t, err)
+		assert.Nil(t, p)
+		assert.Nil(t, rc)
+	})
+}
@@ -0,0 +1,215 @@
+package hasaki
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lxzan/hasaki/internal"
+	"github.com/pkg/errors"
+	"github.com/valyala/bytebufferpool"
+)
+
+type (
+	// JsonEncoder JSON流式编码器, encoding/json, jsoniter和go-json的Encoder均满足该接口
+	// JSON stream encoder, satisfied by the Encoder of encoding/json, jsoniter and go-json
+	JsonEncoder interface {
+		Encode(v any) error
+		SetEscapeHTML(on bool)
+		SetIndent(prefix, indent string)
+	}
+
+	// JsonDecoder JSON流式解码器, encoding/json, jsoniter和go-json的Decoder均满足该接口
+	// JSON stream decoder, satisfied by the Decoder of encoding/json, jsoniter and go-json
+	JsonDecoder interface {
+		Decode(v any) error
+		UseNumber()
+		DisallowUnknownFields()
+	}
+
+	// JsonBackend JSON编解码实现
+	// JSON implementation
+	JsonBackend interface {
+		NewEncoder(w io.Writer) JsonEncoder
+		NewDecoder(r io.Reader) JsonDecoder
+	}
+)
+
+var (
+	// JsonStdBackend 使用标准库encoding/json
+	// Using encoding/json of the standard library
+	JsonStdBackend = NewJsonBackend(
+		func(w io.Writer) JsonEncoder { return json.NewEncoder(w) },
+		func(r io.Reader) JsonDecoder { return json.NewDecoder(r) },
+	)
+
+	// JsoniterFastest 使用jsoniter.ConfigFastest, 浮点数只保留6位小数, 不转义HTML. JsonCodec的默认实现.
+	// Using jsoniter.ConfigFastest, floats are marshaled with 6 digits and HTML is not escaped. The default of JsonCodec.
+	JsoniterFastest JsonBackend = &jsoniterBackend{
+		config: jsoniter.Config{EscapeHTML: false, MarshalFloatWith6Digits: true, ObjectFieldMustBeSimpleString: true},
+		api:    jsoniter.ConfigFastest,
+	}
+
+	// JsoniterDefault 使用jsoniter.ConfigDefault
+	// Using jsoniter.ConfigDefault
+	JsoniterDefault JsonBackend = &jsoniterBackend{
+		config: jsoniter.Config{EscapeHTML: true},
+		api:    jsoniter.ConfigDefault,
+	}
+
+	// JsoniterCompatible 使用jsoniter.ConfigCompatibleWithStandardLibrary, 行为与encoding/json一致
+	// Using jsoniter.ConfigCompatibleWithStandardLibrary, behaves the same as encoding/json
+	JsoniterCompatible JsonBackend = &jsoniterBackend{
+		config: jsoniter.Config{EscapeHTML: true, SortMapKeys: true, ValidateJsonRawMessage: true},
+		api:    jsoniter.ConfigCompatibleWithStandardLibrary,
+	}
+)
+
+type jsonBackend struct {
+	newEncoder func(w io.Writer) JsonEncoder
+	newDecoder func(r io.Reader) JsonDecoder
+}
+
+// NewJsonBackend 使用自定义的编码器和解码器构造函数创建JsonBackend, 例如接入go-json:
+// Creating a JsonBackend from custom encoder and decoder constructors, e.g. plugging in go-json:
+//
+//	hasaki.NewJsonBackend(
+//		func(w io.Writer) hasaki.JsonEncoder { return gojson.NewEncoder(w) },
+//		func(r io.Reader) hasaki.JsonDecoder { return gojson.NewDecoder(r) },
+//	)
+func NewJsonBackend(newEncoder func(w io.Writer) JsonEncoder, newDecoder func(r io.Reader) JsonDecoder) JsonBackend {
+	return &jsonBackend{newEncoder: newEncoder, newDecoder: newDecoder}
+}
+
+func (c *jsonBackend) NewEncoder(w io.Writer) JsonEncoder { return c.newEncoder(w) }
+
+func (c *jsonBackend) NewDecoder(r io.Reader) JsonDecoder { return c.newDecoder(r) }
+
+type jsoniterBackend struct {
+	config jsoniter.Config
+	api    jsoniter.API
+}
+
+func (c *jsoniterBackend) NewEncoder(w io.Writer) JsonEncoder { return c.api.NewEncoder(w) }
+
+func (c *jsoniterBackend) NewDecoder(r io.Reader) JsonDecoder { return c.api.NewDecoder(r) }
+
+type (
+	jsonConfig struct {
+		backend               JsonBackend
+		useNumber             bool
+		disallowUnknownFields bool
+		escapeHTML            *bool
+		prefix                string
+		indent                string
+	}
+
+	// JsonOption JSON编解码器配置
+	// JSON codec option
+	JsonOption func(c *jsonConfig)
+)
+
+// WithJsonBackend 设置JSON实现, 默认为JsoniterFastest
+// Setting the JSON implementation, default is JsoniterFastest
+func WithJsonBackend(backend JsonBackend) JsonOption {
+	return func(c *jsonConfig) {
+		c.backend = backend
+	}
+}
+
+// WithJsonUseNumber 解码到interface{}时数字保留为json.Number, 避免精度丢失
+// Decoding numbers into interface{} as json.Number to avoid losing precision
+func WithJsonUseNumber() JsonOption {
+	return func(c *jsonConfig) {
+		c.useNumber = true
+	}
+}
+
+// WithJsonDisallowUnknownFields 解码时遇到结构体中不存在的字段返回错误
+// Returning an error when decoding a field that does not exist in the struct
+func WithJsonDisallowUnknownFields() JsonOption {
+	return func(c *jsonConfig) {
+		c.disallowUnknownFields = true
+	}
+}
+
+// WithJsonEscapeHTML 设置是否转义字符串中的<, >和&, 默认遵循JsonBackend的行为
+// Setting whether <, > and & in strings are escaped, the JsonBackend's behavior is kept by default
+func WithJsonEscapeHTML(on bool) JsonOption {
+	return func(c *jsonConfig) {
+		c.escapeHTML = &on
+	}
+}
+
+// WithJsonIndent 设置缩进; jsoniter只使用indent的长度作为空格缩进, 忽略prefix
+// Setting indentation; jsoniter only uses the length of indent as spaces and ignores prefix
+func WithJsonIndent(prefix, indent string) JsonOption {
+	return func(c *jsonConfig) {
+		c.prefix, c.indent = prefix, indent
+	}
+}
+
+type jsonCodec struct {
+	conf *jsonConfig
+}
+
+// NewJsonCodec 创建JSON编解码器
+// Creating a JSON codec
+func NewJsonCodec(options ...JsonOption) Codec {
+	var conf = &jsonConfig{backend: JsoniterFastest}
+	for _, f := range options {
+		f(conf)
+	}
+
+	// jsoniter每次设置选项都会重建配置, 所以预先把选项合并到配置中
+	if b, ok := conf.backend.(*jsoniterBackend); ok {
+		var config = b.config
+		config.UseNumber = config.UseNumber || conf.useNumber
+		config.DisallowUnknownFields = config.DisallowUnknownFields || conf.disallowUnknownFields
+		if conf.escapeHTML != nil {
+			config.EscapeHTML = *conf.escapeHTML
+		}
+		if conf.indent != "" {
+			config.IndentionStep = len(conf.indent)
+		}
+		if config != b.config {
+			conf.backend = &jsoniterBackend{config: config, api: config.Froze()}
+		}
+		conf.useNumber, conf.disallowUnknownFields, conf.escapeHTML = false, false, nil
+		conf.prefix, conf.indent = "", ""
+	}
+	return &jsonCodec{conf: conf}
+}
+
+func (c *jsonCodec) Encode(v any) (io.Reader, error) {
+	if v == nil {
+		return nil, nil
+	}
+	w := bytebufferpool.Get()
+	enc := c.conf.backend.NewEncoder(w)
+	if c.conf.escapeHTML != nil {
+		enc.SetEscapeHTML(*c.conf.escapeHTML)
+	}
+	if c.conf.prefix != "" || c.conf.indent != "" {
+		enc.SetIndent(c.conf.prefix, c.conf.indent)
+	}
+	err := enc.Encode(v)
+	r := &internal.CloserWrapper{B: w, R: bytes.NewReader(w.B)}
+	return r, errors.WithStack(err)
+}
+
+func (c *jsonCodec) ContentType() string {
+	return MimeJson
+}
+
+func (c *jsonCodec) Decode(r io.Reader, v any) error {
+	dec := c.conf.backend.NewDecoder(r)
+	if c.conf.useNumber {
+		dec.UseNumber()
+	}
+	if c.conf.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	return errors.WithStack(dec.Decode(v))
+}
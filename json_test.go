@@ -0,0 +1,93 @@
+package hasaki
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJsonCodec(t *testing.T) {
+	var encode = func(codec Codec, v any) string {
+		r, err := codec.Encode(v)
+		assert.NoError(t, err)
+		p, _ := io.ReadAll(r)
+		return string(p)
+	}
+
+	t.Run("default", func(t *testing.T) {
+		assert.Equal(t, JsonCodec.ContentType(), MimeJson)
+		assert.Equal(t, encode(JsonCodec, []any{"<a>", 1.23456789}), "[\"<a>\",1.234568]\n")
+	})
+
+	t.Run("backends", func(t *testing.T) {
+		var v = map[string]any{"v": 1.23456789, "s": "<a>"}
+		var expected = "{\"s\":\"\\u003ca\\u003e\",\"v\":1.23456789}\n"
+		assert.Equal(t, encode(NewJsonCodec(WithJsonBackend(JsonStdBackend)), v), expected)
+		assert.Equal(t, encode(NewJsonCodec(WithJsonBackend(JsoniterCompatible)), v), expected)
+		assert.Equal(t, encode(NewJsonCodec(WithJsonBackend(JsoniterDefault)), []float64{1.23456789}), "[1.23456789]\n")
+	})
+
+	t.Run("custom backend", func(t *testing.T) {
+		var backend = NewJsonBackend(
+			func(w io.Writer) JsonEncoder { return json.NewEncoder(w) },
+			func(r io.Reader) JsonDecoder { return json.NewDecoder(r) },
+		)
+		var codec = NewJsonCodec(WithJsonBackend(backend), WithJsonEscapeHTML(false), WithJsonIndent("", "  "))
+		assert.Equal(t, encode(codec, map[string]string{"s": "<a>"}), "{\n  \"s\": \"<a>\"\n}\n")
+	})
+
+	t.Run("escape html", func(t *testing.T) {
+		assert.Equal(t, encode(NewJsonCodec(WithJsonEscapeHTML(true)), "<a>"), "\"\\u003ca\\u003e\"\n")
+		assert.Equal(t, encode(NewJsonCodec(WithJsonBackend(JsonStdBackend), WithJsonEscapeHTML(false)), "<a>"), "\"<a>\"\n")
+	})
+
+	t.Run("indent", func(t *testing.T) {
+		assert.Equal(t, encode(NewJsonCodec(WithJsonIndent("", "  ")), []int{1}), "[\n  1\n]\n")
+		assert.Equal(t, encode(NewJsonCodec(WithJsonBackend(JsonStdBackend), WithJsonIndent("", "\t")), []int{1}), "[\n\t1\n]\n")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		r, err := NewJsonCodec(WithJsonBackend(JsonStdBackend)).Encode(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, r)
+	})
+
+	t.Run("encode error", func(t *testing.T) {
+		_, err := NewJsonCodec(WithJsonBackend(JsonStdBackend)).Encode(make(chan int))
+		assert.Error(t, err)
+	})
+}
+
+func TestJsonCodec_Decode(t *testing.T) {
+	var text = `{"id":12345678901234567890,"name":"caster"}`
+
+	for _, backend := range []JsonBackend{JsonStdBackend, JsoniterFastest, JsoniterDefault, JsoniterCompatible} {
+		t.Run("use number", func(t *testing.T) {
+			var v map[string]any
+			assert.NoError(t, NewJsonCodec(WithJsonBackend(backend), WithJsonUseNumber()).Decode(strings.NewReader(text), &v))
+			assert.Equal(t, v["id"], json.Number("12345678901234567890"))
+		})
+
+		t.Run("disallow unknown fields", func(t *testing.T) {
+			var v struct {
+				Name string `json:"name"`
+			}
+			assert.NoError(t, NewJsonCodec(WithJsonBackend(backend)).Decode(strings.NewReader(text), &v))
+			assert.Equal(t, v.Name, "caster")
+
+			var codec = NewJsonCodec(WithJsonBackend(backend), WithJsonDisallowUnknownFields())
+			assert.Error(t, codec.Decode(strings.NewReader(text), &v))
+			assert.NoError(t, codec.Decode(bytes.NewReader([]byte(`{"name":"lancer"}`)), &v))
+			assert.Equal(t, v.Name, "lancer")
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		var v map[string]any
+		assert.Error(t, JsonCodec.Decode(strings.NewReader("{"), &v))
+	})
+}
@@ -0,0 +1,217 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/lxzan/hasaki"
+	"github.com/pkg/errors"
+)
+
+const Version = "2.0"
+
+var (
+	errUnexpectedStatus = errors.New("unexpected status code")
+	errMissingResponse  = errors.New("missing response")
+)
+
+type (
+	request struct {
+		Version string  `json:"jsonrpc"`
+		Method  string  `json:"method"`
+		Params  any     `json:"params,omitempty"`
+		ID      *uint64 `json:"id,omitempty"`
+	}
+
+	response struct {
+		Version string          `json:"jsonrpc"`
+		Result  json.RawMessage `json:"result"`
+		Error   *Error          `json:"error"`
+		ID      *uint64         `json:"id"`
+	}
+)
+
+// Client JSON-RPC 2.0客户端, 请求通过hasaki.Client发送, 使用JsonCodec编解码
+// JSON-RPC 2.0 client; requests are sent through hasaki.Client and encoded with JsonCodec
+type Client struct {
+	client *hasaki.Client
+	url    string
+	id     atomic.Uint64
+}
+
+// NewClient 创建JSON-RPC客户端, url为服务端点
+// Creating a JSON-RPC client, url is the service endpoint
+func NewClient(client *hasaki.Client, url string) *Client {
+	return &Client{client: client, url: url}
+}
+
+func (c *Client) nextID() *uint64 {
+	var id = c.id.Add(1)
+	return &id
+}
+
+// Call 调用method并将结果解码到result; result为nil时忽略结果. 服务端返回的错误对象为*Error.
+// Calls method and decodes the result into result; the result is ignored when result is nil. Error objects from the server are *Error.
+func (c *Client) Call(ctx context.Context, method string, params any, result any) error {
+	var req = request{Version: Version, Method: method, Params: params, ID: c.nextID()}
+	var resp response
+	if err := c.send(ctx, req, &resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	return decodeResult(resp.Result, result)
+}
+
+// Notify 发送通知, 服务端不会返回结果
+// Sends a notification, for which the server returns no result
+func (c *Client) Notify(ctx context.Context, method string, params any) error {
+	return c.send(ctx, request{Version: Version, Method: method, Params: params}, nil)
+}
+
+// NewBatch 创建批量请求
+// Creating a batch request
+func (c *Client) NewBatch() *Batch {
+	return &Batch{client: c}
+}
+
+// send 发送请求并解码响应; v为nil时不解析响应体
+func (c *Client) send(ctx context.Context, body any, v any) error {
+	var resp = c.client.Post(c.url).SetContext(ctx).Send(body)
+	if err := resp.Err(); err != nil {
+		return err
+	}
+	p, err := resp.ReadBody()
+	if err != nil {
+		return err
+	}
+	if v == nil || len(bytes.TrimSpace(p)) == 0 {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return errors.Wrapf(errUnexpectedStatus, "%d", resp.StatusCode)
+		}
+		return nil
+	}
+	if err := hasaki.JsonCodec.Decode(bytes.NewReader(p), v); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return errors.Wrapf(errUnexpectedStatus, "%d", resp.StatusCode)
+		}
+		return err
+	}
+	return nil
+}
+
+// Call 批量请求中的一个调用
+// A call in a batch request
+type Call struct {
+	Method string
+	Params any
+	Result any   // 结果的解码目标
+	Error  error // 调用的错误, 服务端返回的错误对象为*Error
+	id     *uint64
+}
+
+// Batch 批量请求, 响应按照id匹配到对应的调用
+// Batch request; responses are matched to their calls by id
+type Batch struct {
+	client *Client
+	calls  []*Call
+	notify []request
+}
+
+// Call 添加一个调用, 结果在Send之后解码到result
+// Adding a call, whose result is decoded into result after Send
+func (c *Batch) Call(method string, params any, result any) *Call {
+	var call = &Call{Method: method, Params: params, Result: result, id: c.client.nextID()}
+	c.calls = append(c.calls, call)
+	return call
+}
+
+// Notify 添加一个通知
+// Adding a notification
+func (c *Batch) Notify(method string, params any) *Batch {
+	c.notify = append(c.notify, request{Version: Version, Method: method, Params: params})
+	return c
+}
+
+// Len 返回调用和通知的总数
+// Returns the total number of calls and notifications
+func (c *Batch) Len() int {
+	return len(c.calls) + len(c.notify)
+}
+
+// Send 发送批量请求. 返回的错误只表示请求本身失败, 每个调用的错误记录在Call.Error中.
+// Sends the batch. The returned error only means the request itself failed; the error of each call is recorded in Call.Error.
+func (c *Batch) Send(ctx context.Context) error {
+	if c.Len() == 0 {
+		return nil
+	}
+	var body = make([]request, 0, c.Len())
+	for _, item := range c.calls {
+		body = append(body, request{Version: Version, Method: item.Method, Params: item.Params, ID: item.id})
+	}
+	body = append(body, c.notify...)
+
+	if len(c.calls) == 0 {
+		return c.client.send(ctx, body, nil)
+	}
+
+	// 服务端无法解析批量请求时返回单个错误对象而不是数组
+	var raw json.RawMessage
+	if err := c.client.send(ctx, body, &raw); err != nil {
+		c.fail(err)
+		return err
+	}
+	var responses []response
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		var resp response
+		if err := hasaki.JsonCodec.Decode(bytes.NewReader(raw), &resp); err != nil {
+			c.fail(err)
+			return err
+		}
+		if resp.Error != nil {
+			c.fail(resp.Error)
+			return resp.Error
+		}
+		responses = append(responses, resp)
+	} else if err := hasaki.JsonCodec.Decode(bytes.NewReader(raw), &responses); err != nil {
+		c.fail(err)
+		return err
+	}
+
+	var index = make(map[uint64]*response, len(responses))
+	for i := range responses {
+		if id := responses[i].ID; id != nil {
+			index[*id] = &responses[i]
+		}
+	}
+	for _, item := range c.calls {
+		resp, ok := index[*item.id]
+		switch {
+		case !ok:
+			item.Error = errors.Wrapf(errMissingResponse, "id %s", strconv.FormatUint(*item.id, 10))
+		case resp.Error != nil:
+			item.Error = resp.Error
+		default:
+			item.Error = decodeResult(resp.Result, item.Result)
+		}
+	}
+	return nil
+}
+
+func (c *Batch) fail(err error) {
+	for _, item := range c.calls {
+		item.Error = err
+	}
+}
+
+func decodeResult(raw json.RawMessage, v any) error {
+	if v == nil || len(raw) == 0 {
+		return nil
+	}
+	return hasaki.JsonCodec.Decode(bytes.NewReader(raw), v)
+}
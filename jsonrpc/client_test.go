@@ -0,0 +1,154 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lxzan/hasaki"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type serverRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  []int           `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+func handle(req serverRequest, notified *int64) map[string]any {
+	if req.ID == nil {
+		atomic.AddInt64(notified, 1)
+		return nil
+	}
+	var resp = map[string]any{"jsonrpc": Version, "id": req.ID}
+	switch req.Method {
+	case "add":
+		resp["result"] = req.Params[0] + req.Params[1]
+	case "fail":
+		resp["error"] = map[string]any{"code": -32000, "message": "server error", "data": map[string]any{"reason": "busy"}}
+	case "drop":
+		return nil
+	default:
+		resp["error"] = map[string]any{"code": CodeMethodNotFound, "message": "Method not found"}
+	}
+	return resp
+}
+
+func TestClient(t *testing.T) {
+	var notified int64
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		p, _ := io.ReadAll(request.Body)
+		var encoder = json.NewEncoder(writer)
+		if p[0] == '[' {
+			var reqs []serverRequest
+			if err := json.Unmarshal(p, &reqs); err != nil || len(reqs) == 0 || reqs[0].Method == "invalid" {
+				encoder.Encode(map[string]any{"jsonrpc": Version, "id": nil, "error": map[string]any{"code": CodeInvalidRequest, "message": "Invalid Request"}})
+				return
+			}
+			var results []map[string]any
+			for i := len(reqs) - 1; i >= 0; i-- {
+				if resp := handle(reqs[i], &notified); resp != nil {
+					results = append(results, resp)
+				}
+			}
+			if len(results) == 0 {
+				writer.WriteHeader(http.StatusNoContent)
+				return
+			}
+			encoder.Encode(results)
+			return
+		}
+
+		var req serverRequest
+		assert.NoError(t, json.Unmarshal(p, &req))
+		assert.Equal(t, req.Version, Version)
+		if resp := handle(req, &notified); resp != nil {
+			encoder.Encode(resp)
+		} else {
+			writer.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	var cli, _ = hasaki.NewClient()
+	var client = NewClient(cli, srv.URL)
+	var ctx = context.Background()
+
+	t.Run("call", func(t *testing.T) {
+		var sum int
+		assert.NoError(t, client.Call(ctx, "add", []int{1, 2}, &sum))
+		assert.Equal(t, sum, 3)
+
+		var err = client.Call(ctx, "fail", nil, nil)
+		var e *Error
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, e.Code, -32000)
+		assert.True(t, e.IsServerError())
+		assert.JSONEq(t, string(e.Data), `{"reason":"busy"}`)
+
+		err = client.Call(ctx, "unknown", nil, nil)
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, e.Code, CodeMethodNotFound)
+		assert.Equal(t, err.Error(), "jsonrpc: -32601 Method not found")
+	})
+
+	t.Run("notify", func(t *testing.T) {
+		atomic.StoreInt64(&notified, 0)
+		assert.NoError(t, client.Notify(ctx, "log", []int{1}))
+		assert.Equal(t, atomic.LoadInt64(&notified), int64(1))
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		atomic.StoreInt64(&notified, 0)
+		var a, b int
+		var batch = client.NewBatch()
+		var c1 = batch.Call("add", []int{1, 2}, &a)
+		var c2 = batch.Call("add", []int{3, 4}, &b)
+		var c3 = batch.Call("fail", nil, nil)
+		var c4 = batch.Call("drop", nil, nil)
+		batch.Notify("log", []int{1})
+		assert.Equal(t, batch.Len(), 5)
+
+		assert.NoError(t, batch.Send(ctx))
+		assert.NoError(t, c1.Error)
+		assert.NoError(t, c2.Error)
+		assert.Equal(t, a, 3)
+		assert.Equal(t, b, 7)
+		var e *Error
+		assert.True(t, errors.As(c3.Error, &e))
+		assert.True(t, errors.Is(c4.Error, errMissingResponse))
+		assert.Equal(t, atomic.LoadInt64(&notified), int64(1))
+	})
+
+	t.Run("batch error", func(t *testing.T) {
+		var batch = client.NewBatch()
+		var call = batch.Call("invalid", nil, nil)
+		var err = batch.Send(ctx)
+		var e *Error
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, e.Code, CodeInvalidRequest)
+		assert.Equal(t, call.Error, err)
+	})
+
+	t.Run("batch notifications", func(t *testing.T) {
+		atomic.StoreInt64(&notified, 0)
+		assert.NoError(t, client.NewBatch().Notify("log", nil).Notify("log", nil).Send(ctx))
+		assert.Equal(t, atomic.LoadInt64(&notified), int64(2))
+		assert.NoError(t, client.NewBatch().Send(ctx))
+	})
+
+	t.Run("status", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.WriteHeader(http.StatusBadGateway)
+		}))
+		defer srv.Close()
+		var err = NewClient(cli, srv.URL).Call(ctx, "add", []int{1, 2}, nil)
+		assert.True(t, errors.Is(err, errUnexpectedStatus))
+	})
+}
@@ -0,0 +1,34 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// 规范定义的错误码
+// Error codes defined by the specification
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Error JSON-RPC错误对象
+// JSON-RPC error object
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (c *Error) Error() string {
+	return "jsonrpc: " + strconv.Itoa(c.Code) + " " + c.Message
+}
+
+// IsServerError 错误码是否位于实现定义的服务端错误区间[-32099, -32000]
+// Whether the code is in the implementation-defined server error range [-32099, -32000]
+func (c *Error) IsServerError() bool {
+	return c.Code >= -32099 && c.Code <= -32000
+}
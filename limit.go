@@ -0,0 +1,72 @@
+package hasaki
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBodyTooLarge 响应体超过WithMaxResponseSize或Request.SetMaxResponseSize设置的上限
+// The response body exceeds the limit set by WithMaxResponseSize or Request.SetMaxResponseSize
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// limitedBody 限制响应体的最大字节数, 超出后每次读取都返回ErrBodyTooLarge
+type limitedBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+	err       error
+}
+
+// newLimitedBody 先检查声明的Content-Length, 再包装响应体在读取时计数; 解压后的响应Content-Length为-1, 按实际读取的字节计数
+// HEAD, 204和304等没有响应体的请求可能声明了Content-Length, 不做检查
+func newLimitedBody(resp *http.Response, limit int64) (*limitedBody, error) {
+	if resp.Body == nil || resp.Body == http.NoBody || !hasResponseBody(resp) {
+		return nil, nil
+	}
+	if resp.ContentLength > limit {
+		_ = resp.Body.Close()
+		return nil, errors.Wrapf(ErrBodyTooLarge, "content-length=%d, limit=%d", resp.ContentLength, limit)
+	}
+	return &limitedBody{ReadCloser: resp.Body, limit: limit, remaining: limit}, nil
+}
+
+func (c *limitedBody) Read(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// 多读一个字节, 区分恰好达到上限和超出上限
+	if int64(len(p))-1 > c.remaining {
+		p = p[:c.remaining+1]
+	}
+	n, err := c.ReadCloser.Read(p)
+	if int64(n) <= c.remaining {
+		c.remaining -= int64(n)
+		return n, err
+	}
+	n = int(c.remaining)
+	c.remaining = 0
+	c.err = errors.Wrapf(ErrBodyTooLarge, "limit=%d", c.limit)
+	return n, c.err
+}
+
+// hasResponseBody http.Client设置超时时会包装http.NoBody, 只能根据请求方法和状态码判断, 见RFC 9110 6.4.1
+func hasResponseBody(resp *http.Response) bool {
+	if resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return false
+	}
+	var code = resp.StatusCode
+	return !(code >= 100 && code < 200) && code != http.StatusNoContent && code != http.StatusNotModified
+}
+
+// exceeded 返回超出上限时的错误; 部分解码器会丢弃读取错误, 需要在解码后再次检查
+func (c *limitedBody) exceeded() error {
+	if c == nil {
+		return nil
+	}
+	return c.err
+}
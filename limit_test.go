@@ -0,0 +1,136 @@
+package hasaki
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type discardDecoder struct{}
+
+func (c discardDecoder) Decode(r io.Reader, v any) error {
+	_, _ = io.Copy(io.Discard, r)
+	return nil
+}
+
+func TestWithMaxResponseSize(t *testing.T) {
+	var text = `{"name":"` + strings.Repeat("a", 990) + `"}` // 1001 bytes
+	var compressed = compressBytes(t, []byte(text), CompressionGzip)
+
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/chunked":
+			// 分块发送, 没有Content-Length
+			writer.Write([]byte(text[:10]))
+			writer.(http.Flusher).Flush()
+			writer.Write([]byte(text[10:]))
+		case "/gzip":
+			writer.Header().Set("Content-Encoding", "gzip")
+			writer.Write(compressed)
+		default:
+			writer.Write([]byte(text))
+		}
+	})
+	go srv.ListenAndServe()
+	defer srv.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	var target = "http://" + addr
+
+	t.Run("content length", func(t *testing.T) {
+		cli, _ := NewClient(WithMaxResponseSize(1000))
+		var resp = cli.Get(target).Send(nil)
+		assert.True(t, errors.Is(resp.Err(), ErrBodyTooLarge))
+		assert.Equal(t, resp.ContentLength, int64(1001))
+
+		resp = cli.Head(target).Send(nil)
+		assert.NoError(t, resp.Err())
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		cli, _ := NewClient(WithMaxResponseSize(1000))
+		var resp = cli.Get(target + "/chunked").Send(nil)
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, resp.ContentLength, int64(-1))
+		_, err := resp.ReadBody()
+		assert.True(t, errors.Is(err, ErrBodyTooLarge))
+
+		var v struct{ Name string }
+		err = cli.Get(target + "/chunked").Send(nil).BindJSON(&v)
+		assert.True(t, errors.Is(err, ErrBodyTooLarge))
+
+		err = cli.Get(target+"/chunked").Send(nil).Bind(&v, discardDecoder{})
+		assert.True(t, errors.Is(err, ErrBodyTooLarge))
+
+		resp = cli.Get(target + "/chunked").Send(nil)
+		p, err := io.ReadAll(resp.Body)
+		assert.True(t, errors.Is(err, ErrBodyTooLarge))
+		assert.Equal(t, len(p), 1000)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("reuse body", func(t *testing.T) {
+		cli, _ := NewClient(WithMaxResponseSize(1000), WithReuseBody())
+		var resp = cli.Get(target + "/chunked").Send(nil)
+		assert.True(t, errors.Is(resp.Err(), ErrBodyTooLarge))
+
+		resp = cli.Get(target + "/chunked").SetMaxResponseSize(1001).Send(nil)
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, string(resp.Body.(BytesReadCloser).Bytes()), text)
+	})
+
+	t.Run("request", func(t *testing.T) {
+		cli, _ := NewClient(WithMaxResponseSize(10))
+		for _, path := range []string{"", "/chunked"} {
+			var v struct{ Name string }
+			assert.NoError(t, cli.Get(target+path).SetMaxResponseSize(1001).Send(nil).BindJSON(&v))
+			assert.Equal(t, len(v.Name), 990)
+
+			p, err := cli.Get(target + path).SetMaxResponseSize(0).Send(nil).ReadBody()
+			assert.NoError(t, err)
+			assert.Equal(t, string(p), text)
+		}
+
+		var resp = Get(target).SetMaxResponseSize(1000).Send(nil)
+		assert.True(t, errors.Is(resp.Err(), ErrBodyTooLarge))
+	})
+
+	t.Run("decompression", func(t *testing.T) {
+		// 按解压后的字节计数
+		cli, _ := NewClient(WithMaxResponseSize(1000), WithDecompression())
+		var resp = cli.Get(target + "/gzip").Send(nil)
+		assert.NoError(t, resp.Err())
+		_, err := resp.ReadBody()
+		assert.True(t, errors.Is(err, ErrBodyTooLarge))
+
+		p, err := cli.Get(target + "/gzip").SetMaxResponseSize(1001).Send(nil).ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), text)
+	})
+}
+
+func TestLimitedBody(t *testing.T) {
+	var body = &limitedBody{ReadCloser: io.NopCloser(strings.NewReader("hello")), limit: 5, remaining: 5}
+	n, err := body.Read(nil)
+	assert.Equal(t, n, 0)
+	assert.NoError(t, err)
+	p, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.Equal(t, string(p), "hello")
+	assert.Nil(t, (*limitedBody)(nil).exceeded())
+
+	body = &limitedBody{ReadCloser: io.NopCloser(strings.NewReader("hello")), limit: 4, remaining: 4}
+	p, err = io.ReadAll(body)
+	assert.True(t, errors.Is(err, ErrBodyTooLarge))
+	assert.Equal(t, string(p), "hell")
+	_, err = body.Read(make([]byte, 8))
+	assert.True(t, errors.Is(body.exceeded(), ErrBodyTooLarge))
+	assert.True(t, errors.Is(err, ErrBodyTooLarge))
+}
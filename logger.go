@@ -0,0 +1,232 @@
+package hasaki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/lxzan/hasaki/internal"
+)
+
+const defaultRedactMask = "******"
+
+// LogConfig 日志配置
+// Logging configuration
+type LogConfig struct {
+	RequestLevel  slog.Level // 请求日志级别
+	ResponseLevel slog.Level // 响应日志级别
+	ErrorLevel    slog.Level // 错误日志级别
+	BodyLimit     int        // body预览的最大字节数, 小于等于0时不记录body
+}
+
+// DefaultLogConfig 默认日志配置
+// Default logging configuration
+var DefaultLogConfig = LogConfig{
+	RequestLevel:  slog.LevelDebug,
+	ResponseLevel: slog.LevelInfo,
+	ErrorLevel:    slog.LevelError,
+	BodyLimit:     1024,
+}
+
+// Redactor 脱敏规则, 同时作用于日志和CURL命令
+// Redaction rules, applied to both logs and cURL commands
+type Redactor struct {
+	Headers    []string // 请求头/响应头名称, 不区分大小写
+	QueryKeys  []string // 查询参数名称
+	JSONFields []string // JSON字段路径, 以.分隔, 例如 user.password; 数组会被逐个展开
+	Mask       string   // 替换文本, 默认为 ******
+}
+
+// DefaultRedactor 默认脱敏规则, 隐藏认证信息和Cookie
+// Default redaction rules, hiding credentials and cookies
+var DefaultRedactor = &Redactor{
+	Headers: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+}
+
+func (c *Redactor) mask() string {
+	if c.Mask == "" {
+		return defaultRedactMask
+	}
+	return c.Mask
+}
+
+// RedactHeader 返回脱敏后的请求头副本
+// Returns a redacted copy of the header
+func (c *Redactor) RedactHeader(header http.Header) http.Header {
+	var h = header.Clone()
+	if c == nil || h == nil {
+		return h
+	}
+	for _, k := range c.Headers {
+		k = http.CanonicalHeaderKey(k)
+		if values, ok := h[k]; ok {
+			var masked = make([]string, len(values))
+			for i := range masked {
+				masked[i] = c.mask()
+			}
+			h[k] = masked
+		}
+	}
+	return h
+}
+
+// RedactURL 返回脱敏后的URL
+// Returns the redacted URL
+func (c *Redactor) RedactURL(rawURL string) string {
+	if c == nil || len(c.QueryKeys) == 0 {
+		return rawURL
+	}
+	URL, err := neturl.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	var query = URL.Query()
+	var changed = false
+	for _, k := range c.QueryKeys {
+		if values, ok := query[k]; ok {
+			for i := range values {
+				values[i] = c.mask()
+			}
+			changed = true
+		}
+	}
+	if changed {
+		URL.RawQuery = query.Encode()
+	}
+	return URL.String()
+}
+
+// RedactBody 返回脱敏后的body. 仅处理JSON; 无法解析的JSON会被整体替换.
+// Returns the redacted body. Only JSON is handled; unparsable JSON is replaced entirely.
+func (c *Redactor) RedactBody(contentType string, body []byte) []byte {
+	if c == nil || len(c.JSONFields) == 0 || len(body) == 0 {
+		return body
+	}
+	var trimmed = bytes.TrimSpace(body)
+	if !strings.Contains(contentType, "json") && !bytes.HasPrefix(trimmed, []byte("{")) && !bytes.HasPrefix(trimmed, []byte("[")) {
+		return body
+	}
+
+	var decoder = json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var data any
+	if err := decoder.Decode(&data); err != nil {
+		return []byte(c.mask())
+	}
+	for _, path := range c.JSONFields {
+		c.redactField(data, strings.Split(path, "."))
+	}
+	p, err := json.Marshal(data)
+	if err != nil {
+		return []byte(c.mask())
+	}
+	return p
+}
+
+func (c *Redactor) redactField(data any, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			c.redactField(item, keys)
+		}
+	case map[string]any:
+		item, ok := v[keys[0]]
+		if !ok {
+			return
+		}
+		if len(keys) == 1 {
+			v[keys[0]] = c.mask()
+			return
+		}
+		c.redactField(item, keys[1:])
+	}
+}
+
+type logger struct {
+	*slog.Logger
+	conf     *LogConfig
+	redactor *Redactor
+}
+
+func (c *logger) bodyPreview(contentType string, p []byte) string {
+	var truncated = len(p) > c.conf.BodyLimit
+	if truncated {
+		p = p[:c.conf.BodyLimit]
+	}
+	var s = string(c.redactor.RedactBody(contentType, p))
+	if truncated {
+		s += "...(truncated)"
+	}
+	return s
+}
+
+func (c *logger) logRequest(ctx context.Context, req *http.Request) {
+	if c == nil || !c.Enabled(ctx, c.conf.RequestLevel) {
+		return
+	}
+	var attrs = []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", c.redactor.RedactURL(req.URL.String())),
+		slog.Any("headers", c.redactor.RedactHeader(req.Header)),
+	}
+	if c.conf.BodyLimit > 0 && req.Body != nil && req.Body != http.NoBody {
+		var p []byte
+		if v, ok := req.Body.(BytesReadCloser); ok {
+			p = v.Bytes()
+		} else {
+			p, req.Body, _ = internal.Peek(req.Body, c.conf.BodyLimit+1)
+		}
+		attrs = append(attrs, slog.String("body", c.bodyPreview(req.Header.Get("Content-Type"), p)))
+	}
+	c.LogAttrs(ctx, c.conf.RequestLevel, "hasaki request", attrs...)
+}
+
+func (c *logger) logResponse(ctx context.Context, resp *http.Response, latency time.Duration) {
+	if c == nil || !c.Enabled(ctx, c.conf.ResponseLevel) {
+		return
+	}
+	var attrs = []slog.Attr{
+		slog.String("method", resp.Request.Method),
+		slog.String("url", c.redactor.RedactURL(resp.Request.URL.String())),
+		slog.Int("status", resp.StatusCode),
+		slog.Duration("latency", latency),
+		slog.Any("headers", c.redactor.RedactHeader(resp.Header)),
+	}
+	if c.conf.BodyLimit <= 0 || resp.Body == nil || resp.Body == http.NoBody {
+		c.LogAttrs(ctx, c.conf.ResponseLevel, "hasaki response", attrs...)
+		return
+	}
+
+	var contentType = resp.Header.Get("Content-Type")
+	if v, ok := resp.Body.(BytesReadCloser); ok {
+		attrs = append(attrs, slog.String("body", c.bodyPreview(contentType, v.Bytes())))
+		c.LogAttrs(ctx, c.conf.ResponseLevel, "hasaki response", attrs...)
+		return
+	}
+
+	// 不能在Send中预读body, 否则流式响应会阻塞; 读取完毕或者关闭时再记录日志
+	resp.Body = internal.Capture(resp.Body, c.conf.BodyLimit+1, func(head []byte, size int64, err error) {
+		attrs = append(attrs, slog.String("body", c.bodyPreview(contentType, head)))
+		c.LogAttrs(ctx, c.conf.ResponseLevel, "hasaki response", attrs...)
+	})
+}
+
+func (c *logger) logError(ctx context.Context, req *http.Request, err error, latency time.Duration) {
+	if c == nil || !c.Enabled(ctx, c.conf.ErrorLevel) {
+		return
+	}
+	c.LogAttrs(ctx, c.conf.ErrorLevel, "hasaki error",
+		slog.String("method", req.Method),
+		slog.String("url", c.redactor.RedactURL(req.URL.String())),
+		slog.Duration("latency", latency),
+		slog.String("error", err.Error()),
+	)
+}
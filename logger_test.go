@@ -0,0 +1,150 @@
+package hasaki
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	var redactor = &Redactor{
+		Headers:    []string{"authorization"},
+		QueryKeys:  []string{"token"},
+		JSONFields: []string{"password", "user.secret"},
+	}
+
+	t.Run("header", func(t *testing.T) {
+		var h = http.Header{}
+		h.Set("Authorization", "Bearer xxx")
+		h.Set("X-Request-Id", "1")
+		var result = redactor.RedactHeader(h)
+		assert.Equal(t, result.Get("Authorization"), defaultRedactMask)
+		assert.Equal(t, result.Get("X-Request-Id"), "1")
+		assert.Equal(t, h.Get("Authorization"), "Bearer xxx")
+	})
+
+	t.Run("url", func(t *testing.T) {
+		var result = redactor.RedactURL("http://127.0.0.1/?token=xxx&page=1")
+		assert.Equal(t, result, "http://127.0.0.1/?page=1&token=%2A%2A%2A%2A%2A%2A")
+		assert.Equal(t, redactor.RedactURL("http://127.0.0.1/?page=1"), "http://127.0.0.1/?page=1")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var body = `{"password":"123","user":[{"secret":"abc","name":"caster"}],"id":12345678901234567890}`
+		var result = string(redactor.RedactBody(MimeJson, []byte(body)))
+		assert.Equal(t, result, `{"id":12345678901234567890,"password":"******","user":[{"name":"caster","secret":"******"}]}`)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		var result = string(redactor.RedactBody(MimeJson, []byte(`{"password":"12`)))
+		assert.Equal(t, result, defaultRedactMask)
+	})
+
+	t.Run("not json", func(t *testing.T) {
+		var result = string(redactor.RedactBody(MimeForm, []byte(`password=123`)))
+		assert.Equal(t, result, "password=123")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var r *Redactor
+		var h = http.Header{"Cookie": []string{"a=1"}}
+		assert.Equal(t, r.RedactHeader(h).Get("Cookie"), "a=1")
+		assert.Equal(t, r.RedactURL("/?token=1"), "/?token=1")
+		assert.Equal(t, string(r.RedactBody(MimeJson, []byte("{}"))), "{}")
+	})
+}
+
+func TestWithLogger(t *testing.T) {
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Set-Cookie", "session=abc")
+		writer.WriteHeader(http.StatusOK)
+		writer.Write([]byte(`{"name":"caster","token":"abc"}`))
+	}))
+	go srv.ListenAndServe()
+	time.Sleep(100 * time.Millisecond)
+
+	t.Run("ok", func(t *testing.T) {
+		var buf = bytes.NewBuffer(nil)
+		var cli, _ = NewClient(
+			WithLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+			WithRedactor(&Redactor{
+				Headers:    []string{"Authorization", "Set-Cookie"},
+				JSONFields: []string{"token", "password"},
+			}),
+		)
+		var resp = cli.Post("http://%s/login", addr).
+			SetHeader("Authorization", "Bearer xxx").
+			Send(Any{"password": "123"})
+		assert.NoError(t, resp.Err())
+		assert.False(t, strings.Contains(buf.String(), `"msg":"hasaki response"`))
+
+		p, err := resp.ReadBody()
+		assert.NoError(t, err)
+		assert.Equal(t, string(p), `{"name":"caster","token":"abc"}`)
+
+		var output = buf.String()
+		assert.True(t, strings.Contains(output, `"msg":"hasaki request"`))
+		assert.True(t, strings.Contains(output, `"msg":"hasaki response"`))
+		assert.True(t, strings.Contains(output, `\"name\":\"caster\"`))
+		assert.False(t, strings.Contains(output, "Bearer xxx"))
+		assert.False(t, strings.Contains(output, "session=abc"))
+		assert.False(t, strings.Contains(output, `\"password\":\"123\"`))
+		assert.False(t, strings.Contains(output, `\"token\":\"abc\"`))
+	})
+
+	t.Run("body limit", func(t *testing.T) {
+		var buf = bytes.NewBuffer(nil)
+		var cli, _ = NewClient(
+			WithReuseBody(),
+			WithLogger(slog.New(slog.NewTextHandler(buf, nil))),
+			WithLogConfig(LogConfig{ResponseLevel: slog.LevelInfo, BodyLimit: 4}),
+		)
+		var resp = cli.Get("http://%s", addr).Send(nil)
+		assert.NoError(t, resp.Err())
+		assert.True(t, strings.Contains(buf.String(), `body="{\"na...(truncated)"`))
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		var addr = nextAddr()
+		var srv = &http.Server{Addr: addr}
+		var release = make(chan struct{})
+		srv.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Content-Type", "text/event-stream")
+			writer.Write([]byte("data: hello\n\n"))
+			writer.(http.Flusher).Flush()
+			<-release
+		})
+		go srv.ListenAndServe()
+		defer srv.Close()
+		time.Sleep(100 * time.Millisecond)
+
+		var buf = bytes.NewBuffer(nil)
+		var cli, _ = NewClient(WithLogger(slog.New(slog.NewTextHandler(buf, nil))))
+		var startTime = time.Now()
+		var resp = cli.Get("http://%s", addr).Send(nil)
+		assert.NoError(t, resp.Err())
+		assert.Less(t, time.Since(startTime), time.Second)
+
+		var p = make([]byte, 64)
+		n, _ := resp.Body.Read(p)
+		assert.Equal(t, string(p[:n]), "data: hello\n\n")
+		close(release)
+		assert.NoError(t, resp.Body.Close())
+		assert.True(t, strings.Contains(buf.String(), `body="data: hello\n\n"`))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var buf = bytes.NewBuffer(nil)
+		var cli, _ = NewClient(WithLogger(slog.New(slog.NewTextHandler(buf, nil))))
+		var resp = cli.Get("http://%s", nextAddr()).Send(nil)
+		assert.Error(t, resp.Err())
+		assert.True(t, strings.Contains(buf.String(), `msg="hasaki error"`))
+	})
+}
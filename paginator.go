@@ -0,0 +1,338 @@
+package hasaki
+
+import (
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+var (
+	errUnexpectedStatus = errors.New("unexpected status code")
+	errItemsNotArray    = errors.New("items path is not an array")
+)
+
+// Page 分页请求的一页
+// A page of a paginated request
+type Page struct {
+	Index    int       // 页码, 从0开始
+	Request  *Request  // 本页的请求
+	Response *Response // 本页的响应, body已被读取
+	Body     []byte    // 本页的响应体, 关闭Response后仍然有效
+}
+
+// PageStrategy 分页策略, 根据当前页构造下一页的请求; 没有下一页时返回nil
+// Pagination strategy which builds the request of the next page from the current page; returns nil when there is no next page
+type PageStrategy interface {
+	Next(page *Page) (*Request, error)
+}
+
+type PageStrategyFunc func(page *Page) (*Request, error)
+
+func (f PageStrategyFunc) Next(page *Page) (*Request, error) {
+	return f(page)
+}
+
+// LinkHeaderStrategy 按照RFC 8288, 从Link响应头的rel="next"获取下一页的URL
+// Gets the URL of the next page from rel="next" of the Link header, as defined by RFC 8288
+func LinkHeaderStrategy() PageStrategy {
+	return PageStrategyFunc(func(page *Page) (*Request, error) {
+		var next = parseLinkHeader(page.Response.Header.Values("Link"))["next"]
+		if next == "" {
+			return nil, nil
+		}
+		base, err := neturl.Parse(page.Request.url)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		URL, err := base.Parse(next)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var request = page.Request.clone()
+		request.url = URL.String()
+		return request, nil
+	})
+}
+
+// CursorStrategy 从响应体的cursorPath字段读取游标, 设置到下一页的param查询参数; 游标为空时结束.
+// cursorPath以.分隔, 例如 meta.next_cursor
+// Reads the cursor from the cursorPath field of the response body and sets it as the param query parameter of the next page;
+// stops when the cursor is empty. cursorPath is separated by dots, e.g. meta.next_cursor
+func CursorStrategy(param string, cursorPath string) PageStrategy {
+	return PageStrategyFunc(func(page *Page) (*Request, error) {
+		var cursor = jsoniter.Get(page.Body, jsonPath(cursorPath)...).ToString()
+		if cursor == "" {
+			return nil, nil
+		}
+		return withQueryParam(page.Request, param, cursor)
+	})
+}
+
+// OffsetStrategy 按照offset分页, 第一页的param为start, 之后每页增加step.
+// 需要配合Paginator.SetItemsPath, 在某一页没有数据时结束.
+// Paginates by offset, param of the first page is start and increases by step for each page.
+// Use it with Paginator.SetItemsPath to stop on an empty page.
+func OffsetStrategy(param string, start int, step int) PageStrategy {
+	return PageStrategyFunc(func(page *Page) (*Request, error) {
+		return withQueryParam(page.Request, param, strconv.Itoa(start+(page.Index+1)*step))
+	})
+}
+
+// PageNumberStrategy 按照页码分页, 第一页的param为start, 之后每页加1.
+// 需要配合Paginator.SetItemsPath, 在某一页没有数据时结束.
+// Paginates by page number, param of the first page is start and increases by 1 for each page.
+// Use it with Paginator.SetItemsPath to stop on an empty page.
+func PageNumberStrategy(param string, start int) PageStrategy {
+	return OffsetStrategy(param, start, 1)
+}
+
+// Paginator 按需逐页请求, 每一页都通过原客户端的中间件发送, 并遵循请求上下文的取消
+// Fetches pages lazily, each page is sent through the middlewares of the original client and respects cancellation of the request context
+type Paginator struct {
+	strategy  PageStrategy
+	body      any
+	itemsPath string
+	stop      func(page *Page) bool
+	maxPages  int
+
+	next *Request
+	page *Page
+	err  error
+}
+
+// NewPaginator 创建分页器, request为第一页的请求
+// Creating a paginator, request is the request of the first page
+func NewPaginator(request *Request, strategy PageStrategy) *Paginator {
+	return &Paginator{next: request, strategy: strategy}
+}
+
+// SetBody 设置每一页的请求体, 与Request.Send的参数相同
+// Setting the request body of every page, the same as the argument of Request.Send
+func (c *Paginator) SetBody(body any) *Paginator {
+	c.body = body
+	return c
+}
+
+// SetItemsPath 设置响应体中数据列表的路径, 以.分隔, 为空时表示整个响应体. 某一页没有数据时结束, 路径不是数组时报错.
+// Setting the path of the item list in the response body, separated by dots; empty means the whole body. Stops on an empty page, fails if the path is not an array.
+func (c *Paginator) SetItemsPath(path string) *Paginator {
+	c.itemsPath = path
+	return c
+}
+
+// SetStop 设置结束条件, fn返回true时当前页是最后一页
+// Setting the stop condition, the current page is the last one when fn returns true
+func (c *Paginator) SetStop(fn func(page *Page) bool) *Paginator {
+	c.stop = fn
+	return c
+}
+
+// SetMaxPages 设置最大页数
+// Setting the maximum number of pages
+func (c *Paginator) SetMaxPages(n int) *Paginator {
+	c.maxPages = n
+	return c
+}
+
+// Next 请求下一页, 没有更多数据或者出错时返回false
+// Fetches the next page, returns false when there is no more data or an error occurred
+func (c *Paginator) Next() bool {
+	if c.next == nil || c.err != nil {
+		return false
+	}
+	var index = 0
+	if c.page != nil {
+		index = c.page.Index + 1
+	}
+	if c.maxPages > 0 && index >= c.maxPages {
+		c.next = nil
+		return false
+	}
+
+	var request = c.next
+	c.next = nil
+	if err := request.ctx.Err(); err != nil {
+		c.err = errors.WithStack(err)
+		return false
+	}
+
+	var resp = request.clone().Send(c.body)
+	if resp.err != nil {
+		c.err = resp.err
+		return false
+	}
+	if _, ok := resp.Body.(BytesReadCloser); !ok {
+		if c.err = request.readBody(resp); c.err != nil {
+			return false
+		}
+	}
+	// 复制body, 响应被关闭后缓冲区会被回收
+	var body = append([]byte(nil), resp.Body.(BytesReadCloser).Bytes()...)
+	if resp.StatusCode >= http.StatusBadRequest {
+		c.err = errors.Wrapf(errUnexpectedStatus, "%d %s", resp.StatusCode, request.url)
+		return false
+	}
+
+	var page = &Page{Index: index, Request: request, Response: resp, Body: body}
+	// 数据列表为空时结束; 设置了路径但不是数组时报错, 未设置路径时只检查顶层数组
+	var items = jsoniter.Get(body, jsonPath(c.itemsPath)...)
+	if c.itemsPath != "" && items.ValueType() != jsoniter.ArrayValue {
+		c.err = errors.Wrapf(errItemsNotArray, "%s %s", c.itemsPath, request.url)
+		return false
+	}
+	if items.ValueType() == jsoniter.ArrayValue && items.Size() == 0 {
+		return false
+	}
+	c.page = page
+
+	if c.stop != nil && c.stop(page) {
+		return true
+	}
+	c.next, c.err = c.strategy.Next(page)
+	return true
+}
+
+// Page 返回当前页
+// Returns the current page
+func (c *Paginator) Page() *Page {
+	return c.page
+}
+
+// Err 返回分页过程中的错误
+// Returns the error that occurred during pagination
+func (c *Paginator) Err() error {
+	return c.err
+}
+
+// ItemIterator 逐条遍历分页数据
+// Iterates over paginated items one by one
+type ItemIterator[T any] struct {
+	pages *Paginator
+	items []T
+	index int
+	err   error
+}
+
+// Items 返回逐条遍历分页数据的迭代器, 数据列表的位置由Paginator.SetItemsPath指定
+// Returns an iterator over paginated items, the location of the item list is given by Paginator.SetItemsPath
+func Items[T any](pages *Paginator) *ItemIterator[T] {
+	return &ItemIterator[T]{pages: pages, index: -1}
+}
+
+// Next 移动到下一条数据, 没有更多数据或者出错时返回false
+// Moves to the next item, returns false when there is no more data or an error occurred
+func (c *ItemIterator[T]) Next() bool {
+	if c.err != nil {
+		return false
+	}
+	c.index++
+	for c.index >= len(c.items) {
+		if !c.pages.Next() {
+			c.err = c.pages.Err()
+			return false
+		}
+		var items []T
+		var value = jsoniter.Get(c.pages.Page().Body, jsonPath(c.pages.itemsPath)...)
+		if value.ToVal(&items); value.LastError() != nil {
+			c.err = errors.WithStack(value.LastError())
+			return false
+		}
+		c.items, c.index = items, 0
+	}
+	return true
+}
+
+// Item 返回当前数据
+// Returns the current item
+func (c *ItemIterator[T]) Item() T {
+	return c.items[c.index]
+}
+
+// Err 返回遍历过程中的错误
+// Returns the error that occurred during iteration
+func (c *ItemIterator[T]) Err() error {
+	return c.err
+}
+
+func withQueryParam(request *Request, key, value string) (*Request, error) {
+	URL, err := neturl.Parse(request.url)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var query = URL.Query()
+	query.Set(key, value)
+	URL.RawQuery = query.Encode()
+	var next = request.clone()
+	next.url = URL.String()
+	return next, nil
+}
+
+func jsonPath(path string) []any {
+	if path == "" {
+		return nil
+	}
+	var keys = strings.Split(path, ".")
+	var results = make([]any, len(keys))
+	for i, k := range keys {
+		results[i] = k
+	}
+	return results
+}
+
+// parseLinkHeader 解析Link响应头, 返回rel到URL的映射
+func parseLinkHeader(values []string) map[string]string {
+	var links = make(map[string]string)
+	for _, value := range values {
+		// URL和带引号的参数中可以包含逗号, 例如 <...?ids=1,2>; rel="next", 见RFC 8288
+		for _, link := range splitOutside(value, ',') {
+			link = strings.TrimSpace(link)
+			var end = strings.IndexByte(link, '>')
+			if !strings.HasPrefix(link, "<") || end < 0 {
+				continue
+			}
+			var target = link[1:end]
+			for _, param := range splitOutside(link[end+1:], ';') {
+				k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(k), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(v), `"`)) {
+					if _, exists := links[strings.ToLower(rel)]; !exists {
+						links[strings.ToLower(rel)] = target
+					}
+				}
+			}
+		}
+	}
+	return links
+}
+
+// splitOutside 按sep拆分s, 忽略<>和双引号中的sep
+func splitOutside(s string, sep byte) []string {
+	var results []string
+	var start, quoted, bracketed = 0, false, false
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case quoted:
+			if ch == '\\' {
+				i++
+			} else if ch == '"' {
+				quoted = false
+			}
+		case ch == '"':
+			quoted = true
+		case ch == '<':
+			bracketed = true
+		case ch == '>':
+			bracketed = false
+		case ch == sep && !bracketed:
+			results = append(results, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(results, s[start:])
+}
@@ -0,0 +1,155 @@
+package hasaki
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginator(t *testing.T) {
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var query = request.URL.Query()
+		switch request.URL.Path {
+		case "/link":
+			page, _ := strconv.Atoi(query.Get("page"))
+			if page < 3 {
+				writer.Header().Add("Link", fmt.Sprintf(`</link?page=%d>; rel="next", </link?page=3>; rel="last"`, page+1))
+			}
+			writer.Write([]byte(fmt.Sprintf(`[{"id":%d}]`, page)))
+		case "/cursor":
+			var next = map[string]string{"": "a", "a": "b", "b": ""}[query.Get("cursor")]
+			writer.Write([]byte(fmt.Sprintf(`{"data":{"items":[{"id":"%s"}]},"meta":{"next":"%s"}}`, query.Get("cursor"), next)))
+		case "/offset":
+			offset, _ := strconv.Atoi(query.Get("offset"))
+			var items = "[]"
+			if offset < 4 {
+				items = fmt.Sprintf(`[{"id":%d},{"id":%d}]`, offset, offset+1)
+			}
+			writer.Write([]byte(`{"items":` + items + `}`))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	go srv.ListenAndServe()
+	time.Sleep(100 * time.Millisecond)
+
+	var sent int64
+	var cli, _ = NewClient(WithBefore(func(ctx context.Context, request *http.Request) (context.Context, error) {
+		atomic.AddInt64(&sent, 1)
+		return ctx, nil
+	}))
+
+	type item struct {
+		Id any `json:"id"`
+	}
+
+	t.Run("link header", func(t *testing.T) {
+		atomic.StoreInt64(&sent, 0)
+		var pages = NewPaginator(cli.Get("http://%s/link?page=1", addr), LinkHeaderStrategy())
+		var ids []any
+		for iter := Items[item](pages); iter.Next(); {
+			ids = append(ids, iter.Item().Id)
+		}
+		assert.NoError(t, pages.Err())
+		assert.Equal(t, ids, []any{float64(1), float64(2), float64(3)})
+		assert.Equal(t, atomic.LoadInt64(&sent), int64(3))
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		var pages = NewPaginator(cli.Get("http://%s/cursor", addr), CursorStrategy("cursor", "meta.next")).
+			SetItemsPath("data.items")
+		var ids []string
+		var bodies [][]byte
+		for pages.Next() {
+			assert.Equal(t, pages.Page().Response.StatusCode, http.StatusOK)
+			ids = append(ids, pages.Page().Request.url)
+			assert.NoError(t, pages.Page().Response.Body.Close())
+			bodies = append(bodies, pages.Page().Body)
+		}
+		assert.Equal(t, string(bodies[0]), `{"data":{"items":[{"id":""}]},"meta":{"next":"a"}}`)
+		assert.NoError(t, pages.Err())
+		assert.Equal(t, len(ids), 3)
+		assert.Equal(t, ids[2], fmt.Sprintf("http://%s/cursor?cursor=b", addr))
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		var iter = Items[item](NewPaginator(cli.Get("http://%s/offset", addr), OffsetStrategy("offset", 0, 2)).SetItemsPath("items"))
+		var count = 0
+		for iter.Next() {
+			assert.Equal(t, iter.Item().Id, float64(count))
+			count++
+		}
+		assert.NoError(t, iter.Err())
+		assert.Equal(t, count, 4)
+	})
+
+	t.Run("items not array", func(t *testing.T) {
+		var pages = NewPaginator(cli.Get("http://%s/cursor", addr), CursorStrategy("cursor", "meta.next")).
+			SetItemsPath("data.list")
+		assert.False(t, pages.Next())
+		assert.True(t, errors.Is(pages.Err(), errItemsNotArray))
+
+		pages = NewPaginator(cli.Get("http://%s/cursor", addr), CursorStrategy("cursor", "meta.next")).
+			SetItemsPath("meta.next")
+		assert.False(t, pages.Next())
+		assert.True(t, errors.Is(pages.Err(), errItemsNotArray))
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		var pages = NewPaginator(cli.Get("http://%s/offset?offset=0", addr), PageNumberStrategy("offset", 0)).
+			SetItemsPath("items").
+			SetStop(func(page *Page) bool { return page.Index == 1 })
+		var count = 0
+		for pages.Next() {
+			count++
+		}
+		assert.Equal(t, count, 2)
+
+		pages = NewPaginator(cli.Get("http://%s/link?page=1", addr), LinkHeaderStrategy()).SetMaxPages(1)
+		count = 0
+		for pages.Next() {
+			count++
+		}
+		assert.Equal(t, count, 1)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		var ctx, cancel = context.WithCancel(context.Background())
+		var pages = NewPaginator(cli.Get("http://%s/link?page=1", addr).SetContext(ctx), LinkHeaderStrategy())
+		assert.True(t, pages.Next())
+		cancel()
+		assert.False(t, pages.Next())
+		assert.True(t, errors.Is(pages.Err(), context.Canceled))
+	})
+
+	t.Run("status", func(t *testing.T) {
+		var iter = Items[item](NewPaginator(cli.Get("http://%s/missing", addr), LinkHeaderStrategy()))
+		assert.False(t, iter.Next())
+		assert.True(t, errors.Is(iter.Err(), errUnexpectedStatus))
+	})
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	var links = parseLinkHeader([]string{
+		`<https://api.github.com/repos?page=2>; rel="next", <https://api.github.com/repos?page=5>; rel="last"`,
+		`<https://api.github.com/repos?page=1>; rel="first prev"`,
+		`invalid; rel="self"`,
+		`<https://api.example.com/items?ids=1,2;3>; title="a, b"; rel="alternate"`,
+	})
+	assert.Equal(t, links, map[string]string{
+		"next":      "https://api.github.com/repos?page=2",
+		"last":      "https://api.github.com/repos?page=5",
+		"first":     "https://api.github.com/repos?page=1",
+		"prev":      "https://api.github.com/repos?page=1",
+		"alternate": "https://api.example.com/items?ids=1,2;3",
+	})
+}
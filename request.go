@@ -3,14 +3,14 @@ package hasaki
 import (
 	"bytes"
 	"context"
-	"fmt"
 	"github.com/lxzan/hasaki/internal"
 	"github.com/pkg/errors"
 	"github.com/valyala/bytebufferpool"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	neturl "net/url"
-	"strings"
+	"time"
 )
 
 var (
@@ -29,7 +29,12 @@ type Request struct {
 	before           BeforeFunc
 	after            AfterFunc
 	debug            bool
+	traceEnabled     bool
 	reuseBodyEnabled bool
+	logger           *logger
+	redactor         *Redactor
+	har              *HARRecorder
+	maxResponseSize  int64
 }
 
 // NewRequest 新建一个请求
@@ -66,13 +71,20 @@ func Options(url string, args ...any) *Request {
 	return defaultClient.Options(url, args...)
 }
 
-// Debug 开启调试模式, 打印CURL命令
-// Enable debug mode, print CURL commands
+// Debug 开启调试模式, 打印CURL命令; 敏感信息会按照脱敏规则隐藏
+// Enable debug mode, print CURL commands; sensitive data is hidden according to the redaction rules
 func (c *Request) Debug() *Request {
 	c.debug = true
 	return c
 }
 
+// EnableTrace 开启请求追踪, 通过Response.TraceInfo获取DNS, 连接, TLS握手, 首字节和传输耗时
+// Enable request tracing, get DNS, connect, TLS handshake, first byte and transfer durations from Response.TraceInfo
+func (c *Request) EnableTrace() *Request {
+	c.traceEnabled = true
+	return c
+}
+
 // SetBefore 设置请求前中间件
 // Setting up pre-request middleware
 func (c *Request) SetBefore(f BeforeFunc) *Request {
@@ -95,6 +107,13 @@ func (c *Request) SetEncoder(encoder Encoder) *Request {
 	return c
 }
 
+// SetMaxResponseSize 设置响应体的最大字节数, 覆盖WithMaxResponseSize; 小于等于0时不限制
+// Setting the maximum size of the response body, overriding WithMaxResponseSize; no limit if less than or equal to 0
+func (c *Request) SetMaxResponseSize(n int64) *Request {
+	c.maxResponseSize = n
+	return c
+}
+
 // SetHeader 设置请求头
 // Set Request Header
 func (c *Request) SetHeader(k, v string) *Request {
@@ -151,23 +170,12 @@ func (c *Request) Send(body any) *Response {
 		return resp
 	}
 
-	reader, err := c.encoder.Encode(body)
+	req, err := c.newHTTPRequest(body)
 	if err != nil {
 		resp.err = err
 		return resp
 	}
 
-	req, err := http.NewRequestWithContext(c.ctx, c.method, c.url, reader)
-	if err != nil {
-		resp.err = errors.WithStack(err)
-		return resp
-	}
-
-	if c.method == http.MethodGet && body == nil {
-		c.headers.Del("Content-Type")
-	}
-	req.Header = c.headers
-
 	// 执行请求前中间件
 	if resp.ctx, resp.err = c.before(c.ctx, req); resp.err != nil {
 		return resp
@@ -175,27 +183,97 @@ func (c *Request) Send(body any) *Response {
 
 	// 打印CURL命令
 	if c.debug {
-		c.printCURL(req)
+		println(dumpCURL(req, c.redactor, true))
+	}
+
+	c.logger.logRequest(resp.ctx, req)
+
+	var session *harSession
+	if c.traceEnabled || c.har != nil {
+		resp.trace = newTraceCollector()
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), resp.trace.clientTrace()))
+	}
+	if c.har != nil {
+		session = c.har.begin(req, c.redactor, resp.trace)
 	}
 
 	// 发起请求
+	var startTime = time.Now()
 	if resp.Response, err = c.client.Do(req); err != nil {
+		c.logger.logError(resp.ctx, req, err, time.Since(startTime))
+		session.finish(nil, err)
 		resp.err = errors.WithStack(err)
 		return resp
 	}
 
+	if resp.trace != nil {
+		resp.trace.set(&resp.trace.gotResponse)
+		// 在body读取完毕或者关闭时记录时间
+		resp.Body = internal.Track(resp.Body, func(size int64, err error) { resp.trace.set(&resp.trace.bodyDone) })
+	}
+
+	// 限制响应体大小
+	if c.maxResponseSize > 0 {
+		if resp.limit, resp.err = newLimitedBody(resp.Response, c.maxResponseSize); resp.err != nil {
+			c.logger.logError(resp.ctx, req, resp.err, time.Since(startTime))
+			session.finish(resp.Response, resp.err)
+			return resp
+		}
+		if resp.limit != nil {
+			resp.Body = resp.limit
+		}
+	}
+
 	// 预先读取body, 可复用
 	if c.reuseBodyEnabled {
 		if resp.err = c.readBody(resp); resp.err != nil {
+			c.logger.logError(resp.ctx, req, resp.err, time.Since(startTime))
+			session.finish(resp.Response, resp.err)
 			return resp
 		}
 	}
 
+	c.logger.logResponse(resp.ctx, resp.Response, time.Since(startTime))
+	session.finish(resp.Response, nil)
+
 	// 执行请求后中间件
 	resp.ctx, resp.err = c.after(resp.ctx, resp.Response)
 	return resp
 }
 
+// clone 复制请求, 请求头独立, 用于重复发送同一个请求
+func (c *Request) clone() *Request {
+	var r = *c
+	r.headers = c.headers.Clone()
+	return &r
+}
+
+func (c *Request) newHTTPRequest(body any) (*http.Request, error) {
+	reader, err := c.encoder.Encode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, c.method, c.url, reader)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if c.method == http.MethodGet && body == nil {
+		c.headers.Del("Content-Type")
+	}
+	// 压缩编码器在请求体小于阈值时不压缩, 需要按实际结果设置Content-Encoding
+	if _, ok := c.encoder.(*compressedEncoder); ok {
+		if r, ok := reader.(*compressedReader); ok {
+			c.headers.Set("Content-Encoding", r.ContentEncoding())
+		} else {
+			c.headers.Del("Content-Encoding")
+		}
+	}
+	req.Header = c.headers
+	return req, nil
+}
+
 func (c *Request) readBody(resp *Response) error {
 	var b = bytebufferpool.Get()
 	var temp = internal.GetBuffer()
@@ -205,36 +283,3 @@ func (c *Request) readBody(resp *Response) error {
 	resp.Body = &internal.CloserWrapper{B: b, R: bytes.NewReader(b.B)}
 	return errors.WithStack(err)
 }
-
-func (c *Request) printCURL(req *http.Request) {
-	var body = bytes.NewBufferString("")
-	if req.Body != nil {
-		_, _ = io.Copy(body, req.Body)
-	}
-
-	var builder = strings.Builder{}
-	var line = fmt.Sprintf("curl -X %s '%s' \\\n", req.Method, req.URL.String())
-	builder.WriteString(line)
-	for k, arr := range req.Header {
-		for _, v := range arr {
-			line = fmt.Sprintf("    --header '%s: %s'", k, v)
-			builder.WriteString(line)
-			if req.Body != nil {
-				builder.WriteString(" \\\n")
-			} else {
-				builder.WriteString(" \n")
-			}
-		}
-	}
-
-	builder.WriteString("    --data-raw ")
-	builder.WriteString("'")
-	if body.Len() < 128*1024 {
-		s := strings.TrimSuffix(body.String(), "\n")
-		s = strings.Replace(s, "'", "\\'", -1)
-		builder.WriteString(s)
-	}
-	builder.WriteString("'")
-	println(builder.String())
-	req.Body = io.NopCloser(body)
-}
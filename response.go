@@ -2,7 +2,9 @@ package hasaki
 
 import (
 	"context"
+	"github.com/lxzan/hasaki/internal"
 	"github.com/pkg/errors"
+	"golang.org/x/text/transform"
 	"io"
 	"net/http"
 	"net/url"
@@ -10,8 +12,10 @@ import (
 
 type Response struct {
 	*http.Response
-	ctx context.Context
-	err error
+	ctx   context.Context
+	err   error
+	trace *traceCollector
+	limit *limitedBody
 }
 
 func (c *Response) Err() error {
@@ -22,6 +26,15 @@ func (c *Response) Context() context.Context {
 	return c.ctx
 }
 
+// TraceInfo 返回请求各阶段的耗时, 需要先调用Request.EnableTrace
+// Returns the duration of each phase of the request, Request.EnableTrace must be called first
+func (c *Response) TraceInfo() TraceInfo {
+	if c.trace == nil {
+		return TraceInfo{}
+	}
+	return c.trace.info()
+}
+
 func (c *Response) ReadBody() ([]byte, error) {
 	if c.err != nil {
 		return nil, c.err
@@ -29,12 +42,25 @@ func (c *Response) ReadBody() ([]byte, error) {
 	if c.Response == nil || c.Body == nil {
 		return nil, errors.WithStack(errEmptyResponse)
 	}
+	var b []byte
 	if v, ok := c.Body.(BytesReadCloser); ok {
-		return v.Bytes(), nil
+		b = v.Bytes()
+	} else {
+		var err error
+		b, err = io.ReadAll(c.Body)
+		_ = c.Body.Close()
+		if e := c.limit.exceeded(); e != nil {
+			err = e
+		}
+		if err != nil {
+			return b, errors.WithStack(err)
+		}
 	}
-	b, err := io.ReadAll(c.Body)
-	_ = c.Body.Close()
-	return b, errors.WithStack(err)
+	if enc := detectCharset(c.contentType(), func() []byte { return b[:min(len(b), charsetSniffLen)] }); enc != nil {
+		p, err := enc.NewDecoder().Bytes(b)
+		return p, errors.WithStack(err)
+	}
+	return b, nil
 }
 
 func (c *Response) BindJSON(v any) error { return c.Bind(v, JsonCodec) }
@@ -50,7 +76,38 @@ func (c *Response) Bind(v any, decoder Decoder) error {
 	if c.Response == nil || c.Body == nil {
 		return errors.WithStack(errEmptyResponse)
 	}
-	err := decoder.Decode(c.Body, v)
+	body, err := c.utf8Body()
+	if err == nil {
+		err = decoder.Decode(body, v)
+	}
 	_ = c.Body.Close()
+	if e := c.limit.exceeded(); e != nil {
+		err = e
+	}
 	return errors.WithStack(err)
 }
+
+func (c *Response) contentType() string {
+	if c.Header == nil {
+		return ""
+	}
+	return c.Header.Get("Content-Type")
+}
+
+// utf8Body 按Content-Type的charset, XML声明或HTML meta标签将响应体转换为UTF-8
+func (c *Response) utf8Body() (io.Reader, error) {
+	var err error
+	var enc = detectCharset(c.contentType(), func() []byte {
+		if b, ok := c.Body.(BytesReadCloser); ok {
+			var p = b.Bytes()
+			return p[:min(len(p), charsetSniffLen)]
+		}
+		var head []byte
+		head, c.Body, err = internal.Peek(c.Body, charsetSniffLen)
+		return head
+	})
+	if err != nil || enc == nil {
+		return c.Body, errors.WithStack(err)
+	}
+	return &charsetReader{Reader: transform.NewReader(c.Body, enc.NewDecoder()), raw: c.Body, encoding: enc}, nil
+}
@@ -0,0 +1,143 @@
+package hasaki
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TraceInfo 请求各阶段的耗时, 缺失的阶段为0
+// Duration of each phase of the request, missing phases are 0
+type TraceInfo struct {
+	DNSLookup       time.Duration // DNS解析耗时
+	ConnTime        time.Duration // TCP连接耗时
+	TLSHandshake    time.Duration // TLS握手耗时
+	ServerTime      time.Duration // 请求写完到收到首字节的耗时
+	FirstByteTime   time.Duration // 开始请求到收到首字节的耗时
+	ContentTransfer time.Duration // 读取body的耗时, body未读取完毕时为0
+	TotalTime       time.Duration // 总耗时, body未读取完毕时截止到收到响应头
+	IsConnReused    bool          // 连接是否复用
+	IsConnWasIdle   bool          // 连接是否来自空闲连接池
+	ConnIdleTime    time.Duration // 连接空闲时长
+	RemoteAddr      net.Addr      // 服务端地址
+}
+
+// traceCollector 通过httptrace收集连接和请求各阶段的时间点
+type traceCollector struct {
+	mu           sync.Mutex
+	start        time.Time
+	getConn      time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	gotResponse  time.Time
+	bodyDone     time.Time
+	reused       bool
+	wasIdle      bool
+	idleTime     time.Duration
+	remoteAddr   net.Addr
+	localAddr    net.Addr
+}
+
+func newTraceCollector() *traceCollector {
+	return &traceCollector{start: time.Now()}
+}
+
+func (c *traceCollector) set(t *time.Time) {
+	c.mu.Lock()
+	*t = time.Now()
+	c.mu.Unlock()
+}
+
+func (c *traceCollector) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(hostPort string) { c.set(&c.getConn) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.gotConn = time.Now()
+			c.reused, c.wasIdle, c.idleTime = info.Reused, info.WasIdle, info.IdleTime
+			if info.Conn != nil {
+				c.remoteAddr, c.localAddr = info.Conn.RemoteAddr(), info.Conn.LocalAddr()
+			}
+		},
+		DNSStart:             func(info httptrace.DNSStartInfo) { c.set(&c.dnsStart) },
+		DNSDone:              func(info httptrace.DNSDoneInfo) { c.set(&c.dnsDone) },
+		ConnectStart:         func(network, addr string) { c.set(&c.connectStart) },
+		ConnectDone:          func(network, addr string, err error) { c.set(&c.connectDone) },
+		TLSHandshakeStart:    func() { c.set(&c.tlsStart) },
+		TLSHandshakeDone:     func(state tls.ConnectionState, err error) { c.set(&c.tlsDone) },
+		WroteRequest:         func(info httptrace.WroteRequestInfo) { c.set(&c.wroteRequest) },
+		GotFirstResponseByte: func() { c.set(&c.firstByte) },
+	}
+}
+
+// snapshot 返回一份加锁读取的副本
+func (c *traceCollector) snapshot() *traceCollector {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &traceCollector{
+		start:        c.start,
+		getConn:      c.getConn,
+		dnsStart:     c.dnsStart,
+		dnsDone:      c.dnsDone,
+		connectStart: c.connectStart,
+		connectDone:  c.connectDone,
+		tlsStart:     c.tlsStart,
+		tlsDone:      c.tlsDone,
+		gotConn:      c.gotConn,
+		wroteRequest: c.wroteRequest,
+		firstByte:    c.firstByte,
+		gotResponse:  c.gotResponse,
+		bodyDone:     c.bodyDone,
+		reused:       c.reused,
+		wasIdle:      c.wasIdle,
+		idleTime:     c.idleTime,
+		remoteAddr:   c.remoteAddr,
+		localAddr:    c.localAddr,
+	}
+}
+
+func (c *traceCollector) info() TraceInfo {
+	var trace = c.snapshot()
+	var end = trace.gotResponse
+	if !trace.bodyDone.IsZero() {
+		end = trace.bodyDone
+	}
+	return TraceInfo{
+		DNSLookup:       positive(between(trace.dnsStart, trace.dnsDone)),
+		ConnTime:        positive(between(trace.connectStart, trace.connectDone)),
+		TLSHandshake:    positive(between(trace.tlsStart, trace.tlsDone)),
+		ServerTime:      positive(between(trace.wroteRequest, trace.firstByte)),
+		FirstByteTime:   positive(between(trace.start, trace.firstByte)),
+		ContentTransfer: positive(between(trace.firstByte, trace.bodyDone)),
+		TotalTime:       positive(between(trace.start, end)),
+		IsConnReused:    trace.reused,
+		IsConnWasIdle:   trace.wasIdle,
+		ConnIdleTime:    trace.idleTime,
+		RemoteAddr:      trace.remoteAddr,
+	}
+}
+
+func positive(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// between 返回两个时间点的间隔, 任一时间点缺失时返回-1
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return -1
+	}
+	return end.Sub(start)
+}
@@ -0,0 +1,53 @@
+package hasaki
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest_EnableTrace(t *testing.T) {
+	addr := nextAddr()
+	srv := &http.Server{Addr: addr}
+	srv.Handler = http.Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		writer.WriteHeader(http.StatusOK)
+		writer.Write([]byte("hello"))
+	}))
+	go srv.ListenAndServe()
+	time.Sleep(100 * time.Millisecond)
+
+	var cli, _ = NewClient()
+
+	t.Run("ok", func(t *testing.T) {
+		var resp = cli.Get("http://%s", addr).EnableTrace().Send(nil)
+		assert.NoError(t, resp.Err())
+		var info = resp.TraceInfo()
+		assert.Greater(t, info.ConnTime, time.Duration(0))
+		assert.GreaterOrEqual(t, info.ServerTime, 20*time.Millisecond)
+		assert.GreaterOrEqual(t, info.FirstByteTime, info.ServerTime)
+		assert.Equal(t, info.ContentTransfer, time.Duration(0))
+		assert.False(t, info.IsConnReused)
+		assert.Equal(t, info.RemoteAddr.String(), addr)
+
+		_, err := resp.ReadBody()
+		assert.NoError(t, err)
+		info = resp.TraceInfo()
+		assert.Greater(t, info.ContentTransfer, time.Duration(0))
+		assert.GreaterOrEqual(t, info.TotalTime, info.FirstByteTime+info.ContentTransfer)
+	})
+
+	t.Run("reused", func(t *testing.T) {
+		var resp = cli.Get("http://%s", addr).EnableTrace().Send(nil)
+		assert.NoError(t, resp.Err())
+		assert.True(t, resp.TraceInfo().IsConnReused)
+		assert.Equal(t, resp.TraceInfo().ConnTime, time.Duration(0))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var resp = cli.Get("http://%s", addr).Send(nil)
+		assert.Equal(t, resp.TraceInfo(), TraceInfo{})
+	})
+}